@@ -2,13 +2,17 @@ package oaichecker
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/go-openapi/analysis"
 	"github.com/go-openapi/runtime"
@@ -21,9 +25,59 @@ import (
 // Analyzer analyze a pair of http.Request/http.Response with the previously
 // Specs loaded at initialization.
 type Analyzer struct {
-	analyzer *analysis.Spec
-	schema   *spec.Schema
-	router   *denco.Router
+	// routes contains a *routes, swapped atomically when the specs are
+	// reloaded.
+	routes atomic.Value
+
+	options AnalyzerOptions
+
+	codecsLock sync.RWMutex
+	codecs     map[string]Codec
+}
+
+// AnalyzerOptions enable the optional checks of an Analyzer, see
+// NewAnalyzerWithOptions.
+type AnalyzerOptions struct {
+	// RejectUnknownParameters report the query parameters and the form
+	// fields not declared by the operation.
+	RejectUnknownParameters bool
+
+	// RejectUnknownHeaders report the request headers not declared by the
+	// operation. The standard HTTP headers, the API keys declared by the
+	// security definitions and the IgnoredHeaders are allowed.
+	RejectUnknownHeaders bool
+	IgnoredHeaders       []string
+
+	// RejectUnknownRequestProperties and RejectUnknownResponseProperties
+	// consider the object schemas without explicit "additionalProperties"
+	// as closed when validating the request and the response bodies
+	// respectively. Each undocumented property is reported.
+	RejectUnknownRequestProperties  bool
+	RejectUnknownResponseProperties bool
+
+	// NullAsAbsent consider the body properties set to null as absent: they
+	// are accepted if optional and reported as missing if required.
+	NullAsAbsent bool
+}
+
+// routes is the part of the Analyzer built from the Specs.
+type routes struct {
+	swagger   *spec.Swagger
+	analyzer  *analysis.Spec
+	router    *denco.Router
+	basePaths []string
+
+	// openAPI3 is set when the specs are converted from OpenAPI 3, the spec
+	// pointers being then mapped back to the original document.
+	openAPI3 bool
+
+	// definitions are the expanded definitions, referenced by the
+	// discriminators.
+	definitions map[string]*spec.Schema
+
+	// schemas contains the body schemas transformed according to the
+	// options, indexed by schemaKey. It is dropped with the specs.
+	schemas sync.Map
 }
 
 // NewAnalyzer instantiate a new Analyzer based on the given Specs.
@@ -34,14 +88,68 @@ func NewAnalyzer(specs *Specs) *Analyzer {
 		panic("specs is nil")
 	}
 
-	return &Analyzer{
-		analyzer: specs.document.Analyzer,
-		schema:   specs.document.Schema(),
-		router:   createRouter(specs.document.Analyzer),
+	r, err := newRoutes(specs)
+	if err != nil {
+		panic(err)
+	}
+
+	analyzer := Analyzer{}
+	analyzer.routes.Store(r)
+
+	return &analyzer
+}
+
+// NewAnalyzerWithOptions instantiate a new Analyzer based on the given Specs,
+// with the optional checks enabled by opts.
+//
+// With the zero AnalyzerOptions, it behaves like NewAnalyzer.
+func NewAnalyzerWithOptions(specs *Specs, opts AnalyzerOptions) *Analyzer {
+	analyzer := NewAnalyzer(specs)
+	analyzer.options = opts
+
+	return analyzer
+}
+
+func newRoutes(specs *Specs) (*routes, error) {
+	router, err := createRouter(specs.document.Analyzer)
+	if err != nil {
+		return nil, err
+	}
+
+	r := routes{
+		swagger:   specs.document.Spec(),
+		analyzer:  specs.document.Analyzer,
+		router:    router,
+		basePaths: sortBasePaths(specs.basePaths),
+		openAPI3:  specs.version != swaggerVersion,
+
+		definitions: map[string]*spec.Schema{},
+	}
+
+	for name, definition := range specs.document.Spec().Definitions {
+		definition := definition
+		r.definitions[name] = &definition
+	}
+
+	return &r, nil
+}
+
+// sortBasePaths normalize the given base paths and sort them from the longest
+// to the shortest, in order to always strip the most specific one.
+func sortBasePaths(basePaths []string) []string {
+	res := make([]string, 0, len(basePaths))
+	for _, basePath := range basePaths {
+		res = append(res, strings.TrimSuffix(basePath, "/"))
 	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return len(res[i]) > len(res[j])
+	})
+
+	return res
 }
 
-func createRouter(analyzer *analysis.Spec) *denco.Router {
+func createRouter(analyzer *analysis.Spec) (*denco.Router, error) {
 	var records []denco.Record
 	for _, paths := range analyzer.Operations() {
 		for pathName := range paths {
@@ -61,146 +169,580 @@ func createRouter(analyzer *analysis.Spec) *denco.Router {
 	r := denco.New()
 	err := r.Build(records)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	return r
+	return r, nil
 }
 
 // Analyze the given pair of http.Request/http.Response with the previously
 // loaded Specs.
 //
 // This method checks:
+// - If the request path is inside the specs basePath
 // - If the Operation exists (method / path)
 // - The Parameters defined inside the Operation (path / header / body / query / formData)
 // - The Response (status / body)
 //
-// In case of incorrectess, an error is returned.
+// In case of incorrectess, an error is returned. All the violations of the
+// specs found inside the request and the response are returned together as
+// ValidationErrors, the first ValidationError can be retrieved with errors.As.
 func (t *Analyzer) Analyze(req *http.Request, res *http.Response) error {
+	ex, pathParams, err := t.exchangeFor(req)
+	if err != nil {
+		return err
+	}
+
+	if res == nil {
+		return errors.New("no response defined")
+	}
+
+	t.validateRequest(ex, req, pathParams)
+	t.validateResponse(ex, res, ex.operation.Responses)
+
+	return ex.err()
+}
+
+// AnalyzeRequest analyze only the given http.Request, for example before
+// sending it.
+//
+// The checks and the returned errors are the same as for Analyze, without the
+// response ones.
+func (t *Analyzer) AnalyzeRequest(req *http.Request) error {
+	ex, pathParams, err := t.exchangeFor(req)
+	if err != nil {
+		return err
+	}
+
+	t.validateRequest(ex, req, pathParams)
+
+	return ex.err()
+}
+
+// AnalyzeResponse analyze only the given http.Response, the http.Request
+// being used to find its Operation.
+//
+// The checks and the returned errors are the same as for Analyze, without the
+// request ones.
+func (t *Analyzer) AnalyzeResponse(req *http.Request, res *http.Response) error {
+	ex, _, err := t.exchangeFor(req)
+	if err != nil {
+		return err
+	}
+
+	if res == nil {
+		return errors.New("no response defined")
+	}
+
+	t.validateResponse(ex, res, ex.operation.Responses)
+
+	return ex.err()
+}
+
+// exchangeFor find the Operation matching the given request.
+func (t *Analyzer) exchangeFor(req *http.Request) (*exchange, denco.Params, error) {
 	if req == nil {
-		return errors.New("no request defined")
+		return nil, nil, errors.New("no request defined")
 	}
 
-	pathName, pathParams, ok := t.router.Lookup(req.URL.Path)
+	r := t.routes.Load().(*routes)
+
+	path, err := r.trimBasePath(req.URL.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pathName, pathParams, ok := r.router.Lookup(path)
 	if !ok {
-		return errors.New("operation not defined inside the specs")
+		return nil, nil, errors.New("operation not defined inside the specs")
 	}
 
-	operation, ok := t.analyzer.OperationFor(req.Method, pathName.(string))
+	operation, ok := r.analyzer.OperationFor(req.Method, pathName.(string))
 	if !ok {
-		return errors.New("operation not defined inside the specs")
+		return nil, nil, errors.New("operation not defined inside the specs")
+	}
+
+	ex := exchange{
+		method:    req.Method,
+		path:      pathName.(string),
+		operation: operation,
+		consumes:  mediaTypesFor(operation.Consumes, r.swagger.Consumes),
+		produces:  mediaTypesFor(operation.Produces, r.swagger.Produces),
+		accept:    req.Header.Get("Accept"),
+		apiKeys:   apiKeys(r.analyzer.SecurityDefinitionsFor(operation)),
+
+		routes: r,
+	}
+
+	ex.parameters = ex.operationParameters(r.swagger.Paths.Paths[ex.path])
+
+	return &ex, pathParams, nil
+}
+
+// operationParameter is a parameter applying to an operation, with the JSON
+// pointer to its declaration.
+type operationParameter struct {
+	spec.Parameter
+
+	pointer string
+}
+
+// parameterPointer return the JSON pointer to the declaration of the
+// parameter at index, relatively to base. The parameters converted from
+// OpenAPI 3 keep the pointer to their original declaration.
+func parameterPointer(base string, index int, param spec.Parameter) string {
+	if pointer, ok := param.Extensions.GetString(pointerExtension); ok {
+		return base + pointer
+	}
+
+	return base + "/parameters/" + strconv.Itoa(index)
+}
+
+// operationParameters return the parameters of the operation completed by
+// the ones of its path item it doesn't override.
+func (t *exchange) operationParameters(item spec.PathItem) []operationParameter {
+	var res []operationParameter
+
+	for i, param := range item.Parameters {
+		overridden := false
+		for _, operationParam := range t.operation.Parameters {
+			if operationParam.Name == param.Name && operationParam.In == param.In {
+				overridden = true
+			}
+		}
+
+		if !overridden {
+			res = append(res, operationParameter{param, parameterPointer(t.pathItemPointer(), i, param)})
+		}
+	}
+
+	for i, param := range t.operation.Parameters {
+		res = append(res, operationParameter{param, parameterPointer(t.specPointer(), i, param)})
+	}
+
+	return res
+}
+
+func (t *Analyzer) validateRequest(ex *exchange, req *http.Request, pathParams denco.Params) {
+	if req.Body != nil && req.Body != http.NoBody {
+		t.validateRequestContentType(ex, req)
 	}
 
-	for _, param := range operation.Parameters {
+	for _, param := range ex.parameters {
 		var err error
 
 		switch param.In {
 		case "path":
-			err = t.validatePathParameter(pathParams, &param)
+			err = t.validatePathParameter(pathParams, &param.Parameter)
 		case "header":
-			err = t.validateHeaderParameter(req, &param)
+			err = t.validateHeaderParameter(req, &param.Parameter)
 		case "body":
-			err = t.validateBodyParameter(req, &param)
+			err = t.validateBodyParameter(ex, req, &param.Parameter)
 		case "query":
-			err = t.validateQueryParameter(req, &param)
+			err = t.validateQueryParameter(req, &param.Parameter)
 		case "formData":
-			err = t.validateFormDataParameter(req, &param)
+			err = t.validateFormDataParameter(ex, req, &param.Parameter)
 		}
-		if err != nil {
-			return err
+
+		ex.add(PhaseRequest, param.In, param.Name, param.pointer, err)
+	}
+
+	if t.options.RejectUnknownParameters {
+		t.validateUnknownParameters(ex, req)
+	}
+
+	if t.options.RejectUnknownHeaders {
+		t.validateUnknownHeaders(ex, req)
+	}
+}
+
+// trimBasePath remove the specs basePath (or the OpenAPI 3 servers path) from
+// the given request path.
+func (t *routes) trimBasePath(path string) (string, error) {
+	if len(t.basePaths) == 0 {
+		return path, nil
+	}
+
+	for _, basePath := range t.basePaths {
+		switch {
+		case basePath == "":
+			return path, nil
+		case path == basePath:
+			return "/", nil
+		case strings.HasPrefix(path, basePath+"/"):
+			return strings.TrimPrefix(path, basePath), nil
+		}
+	}
+
+	return "", fmt.Errorf("request path %q is outside of the specs basePath %q", path, strings.Join(t.basePaths, ", "))
+}
+
+func (t *Analyzer) validateResponse(ex *exchange, res *http.Response, resSpec *spec.Responses) {
+	response, code, ok := responseFor(resSpec, res.StatusCode)
+	if !ok {
+		ex.add(PhaseResponse, "status", "", ex.specPointer("responses"), violation{
+			keyword: "responses",
+			message: fmt.Sprintf("response status %s not defined inside the specs", res.Status),
+		})
+		return
+	}
+
+	specPointer := ex.specPointer("responses", code)
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		ex.add(PhaseResponse, "body", "", specPointer, err)
+		return
+	}
+
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	t.validateResponseHeaders(ex, res, response, specPointer)
+
+	if len(body) > 0 {
+		t.validateResponseContentType(ex, res, specPointer)
+	}
+
+	if response.ResponseProps.Schema == nil {
+		if len(body) > 0 {
+			ex.add(PhaseResponse, "body", "", specPointer, violation{
+				keyword: "schema",
+				message: fmt.Sprintf("no response body defined inside the specs but have %q", body),
+			})
 		}
+		return
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	schema := t.bodySchema(ex.routes, t.contentSchema(response.Extensions, contentType, response.Schema), PhaseResponse)
+
+	schemaPointer := specPointer + "/schema"
+	if ex.routes.openAPI3 {
+		schemaPointer = specPointer + "/content"
+	}
+
+	codec, ok := t.codecFor(contentType)
+	if !ok {
+		return
 	}
 
-	err := t.validateResponse(res, operation.Responses)
+	input, err := codec(body, schema)
+	if err != nil {
+		ex.add(PhaseResponse, "body", "", schemaPointer, violation{
+			message: fmt.Sprintf("failed to parse response body: %s", err),
+		})
+		return
+	}
+
+	if t.options.NullAsAbsent {
+		input = dropNulls(input)
+	}
 
-	return err
+	err = t.validateBody(ex, input, schema, PhaseResponse)
+	ex.add(PhaseResponse, "body", "", schemaPointer, err)
 }
 
-func (t *Analyzer) validateResponse(res *http.Response, resSpec *spec.Responses) error {
-	for status, response := range resSpec.StatusCodeResponses {
-		if status == res.StatusCode {
+// responseFor find the response specs matching the given status code, along
+// with its code inside the specs.
+//
+// The explicit status codes have the priority over the OpenAPI 3 ranges
+// ("2XX"), themselves having the priority over the "default" response.
+func responseFor(resSpec *spec.Responses, status int) (*spec.Response, string, bool) {
+	if resSpec == nil {
+		return nil, "", false
+	}
 
-			body, err := ioutil.ReadAll(res.Body)
-			if err != nil {
-				return err
-			}
+	if response, ok := resSpec.StatusCodeResponses[status]; ok {
+		return &response, strconv.Itoa(status), true
+	}
 
-			res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	ranges, _ := resSpec.Extensions[rangesExtension].(map[string]*spec.Response)
+	code := strconv.Itoa(status/100) + "XX"
+	if response, ok := ranges[code]; ok {
+		return response, code, true
+	}
 
-			if response.ResponseProps.Schema == nil {
-				if len(body) > 0 {
-					return fmt.Errorf("validation failure list:\nno response body defined inside the specs but have %q", body)
-				}
-				return nil
-			}
+	if resSpec.Default != nil {
+		return resSpec.Default, "default", true
+	}
 
-			var input interface{}
-			err = json.Unmarshal(body, &input)
-			if err != nil {
-				return fmt.Errorf("validation failure list:\nfailed to parse response body: %s", err)
-			}
+	return nil, "", false
+}
+
+// validateRequestContentType check that the request Content-Type is allowed
+// by the operation consumes. A missing Content-Type is not reported.
+func (t *Analyzer) validateRequestContentType(ex *exchange, req *http.Request) {
+	contentType := req.Header.Get("Content-Type")
+	if contentType == "" || len(ex.consumes) == 0 {
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !matchMediaType(mediaType, ex.consumes) {
+		ex.add(PhaseRequest, "header", "Content-Type", ex.mediaTypesPointer("consumes", ""), violation{
+			keyword: "consumes",
+			message: fmt.Sprintf("unsupported media type %q, only %v are allowed", contentType, ex.consumes),
+		})
+	}
+}
+
+// validateResponseContentType check that the response Content-Type is allowed
+// by the operation produces and by the request Accept header. A missing
+// Content-Type is not reported.
+//
+// responsePointer is the JSON pointer to the response specs.
+func (t *Analyzer) validateResponseContentType(ex *exchange, res *http.Response, responsePointer string) {
+	contentType := res.Header.Get("Content-Type")
+	if contentType == "" {
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || (len(ex.produces) > 0 && !matchMediaType(mediaType, ex.produces)) {
+		ex.add(PhaseResponse, "header", "Content-Type", ex.mediaTypesPointer("produces", responsePointer), violation{
+			keyword: "produces",
+			message: fmt.Sprintf("unsupported media type %q, only %v are allowed", contentType, ex.produces),
+		})
+		return
+	}
+
+	if ex.accept != "" && !acceptsMediaType(ex.accept, mediaType) {
+		ex.add(PhaseResponse, "header", "Content-Type", "", violation{
+			keyword: "accept",
+			message: fmt.Sprintf("media type %q doesn't match the request Accept header %q", mediaType, ex.accept),
+		})
+	}
+}
 
-			err = validate.AgainstSchema(response.Schema, input, strfmt.Default)
-			if err != nil {
-				return err
+// validateResponseHeaders check the headers declared by the response specs.
+//
+// The missing headers are reported only if they are marked as required, which
+// is possible only with OpenAPI 3.
+func (t *Analyzer) validateResponseHeaders(ex *exchange, res *http.Response, response *spec.Response, specPointer string) {
+	for _, name := range sortedHeaderNames(response.Headers) {
+		header := response.Headers[name]
+		headerPointer := specPointer + "/headers/" + name
+
+		values, ok := res.Header[http.CanonicalHeaderKey(name)]
+		if !ok || len(values) == 0 {
+			if required, _ := header.Extensions.GetBool(requiredExtension); required {
+				ex.add(PhaseResponse, "header", name, headerPointer, violation{
+					keyword: "required",
+					message: fmt.Sprintf("%s in header is required", name),
+				})
 			}
+			continue
+		}
+
+		value, err := coerceValues(name, "header", &header.SimpleSchema, values)
+		if err != nil {
+			ex.add(PhaseResponse, "header", name, headerPointer, err)
+			continue
+		}
 
-			return nil
+		errs := validate.NewHeaderValidator(name, &header, strfmt.Default).Validate(value)
+		if errs != nil {
+			ex.add(PhaseResponse, "header", name, headerPointer, errs.AsError())
 		}
 	}
+}
+
+// sortedHeaderNames return the names of the given headers, sorted in order
+// to always report the violations in the same order.
+func sortedHeaderNames(headers map[string]spec.Header) []string {
+	res := make([]string, 0, len(headers))
+	for name := range headers {
+		res = append(res, name)
+	}
 
-	return fmt.Errorf("validation failure list:\nresponse status %s not defined inside the specs", res.Status)
+	sort.Strings(res)
+
+	return res
 }
 
-func (t *Analyzer) validateBodyParameter(req *http.Request, param *spec.Parameter) error {
-	bodyReader, err := req.GetBody()
+func (t *Analyzer) validateBodyParameter(ex *exchange, req *http.Request, param *spec.Parameter) error {
+	bodyReader, err := requestBody(req)
 	if err != nil {
 		return err
 	}
 
-	input := map[string]interface{}{}
-	err = json.NewDecoder(bodyReader).Decode(&input)
+	body, err := ioutil.ReadAll(bodyReader)
 	if err != nil {
 		return err
 	}
 
-	err = validate.AgainstSchema(param.Schema, input, strfmt.Default)
+	// An empty body is a missing body, not an empty document, whatever its
+	// media type.
+	if len(body) == 0 {
+		if param.Required {
+			return violation{
+				keyword: "required",
+				message: fmt.Sprintf("%s in body is required", param.Name),
+			}
+		}
+		return nil
+	}
+
+	contentType := req.Header.Get("Content-Type")
+	schema := t.bodySchema(ex.routes, t.contentSchema(param.Extensions, contentType, param.Schema), PhaseRequest)
+
+	codec, ok := t.codecFor(contentType)
+	if !ok {
+		return nil
+	}
+
+	input, err := codec(body, schema)
 	if err != nil {
-		return err
+		return violation{message: fmt.Sprintf("failed to parse request body: %s", err)}
 	}
 
-	return nil
+	if t.options.NullAsAbsent {
+		input = dropNulls(input)
+	}
+
+	return t.validateBody(ex, input, schema, PhaseRequest)
 }
 
-func (t *Analyzer) validateHeaderParameter(req *http.Request, param *spec.Parameter) error {
-	errs := validate.NewParamValidator(param, strfmt.Default).Validate(req.Header.Get(param.Name))
-	if errs != nil {
-		return errs.AsError()
+// requestBody return a reader on the request body, leaving the request body
+// untouched for the next readers.
+//
+// The outgoing requests provide GetBody while the incoming ones have to be
+// buffered.
+func requestBody(req *http.Request) (io.Reader, error) {
+	if req.GetBody != nil {
+		return req.GetBody()
 	}
 
-	return nil
+	if req.Body == nil || req.Body == http.NoBody {
+		return bytes.NewReader(nil), nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return bytes.NewReader(body), nil
 }
 
-func (t *Analyzer) validateQueryParameter(req *http.Request, param *spec.Parameter) error {
-	query := req.URL.Query()
+// maxFormMemory is the memory used to parse the multipart forms, the same as
+// http.Request.FormFile.
+const maxFormMemory = 32 << 20
 
-	errs := validate.NewParamValidator(param, strfmt.Default).Validate(query[param.Name])
-	if errs != nil {
-		return errs.AsError()
+// formRequest return a copy of the request with its form, urlencoded or
+// multipart, parsed. Parsing the form consumes the body, which is kept
+// readable inside the original request.
+//
+// The copy is made once per exchange.
+func (t *exchange) formRequest(req *http.Request) (*http.Request, error) {
+	if t.form != nil {
+		return t.form, nil
 	}
 
-	return nil
+	body, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	form := *req
+	form.Body = ioutil.NopCloser(body)
+	form.Form = nil
+	form.PostForm = nil
+	form.MultipartForm = nil
+
+	// The malformed forms are handled as empty ones.
+	_ = form.ParseMultipartForm(maxFormMemory)
+
+	t.form = &form
+
+	return t.form, nil
+}
+
+// contentSchema retrieve the schema matching the given Content-Type inside
+// the content map of an OpenAPI 3 request body or response.
+//
+// The default schema is returned for the Swagger 2.0 specs or if no media
+// type matches.
+func (t *Analyzer) contentSchema(ext spec.Extensions, contentType string, defaultSchema *spec.Schema) *spec.Schema {
+	content, ok := ext[contentExtension].(map[string]*spec.Schema)
+	if !ok {
+		return defaultSchema
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return defaultSchema
+	}
+
+	schema, ok := content[mediaType]
+	if !ok {
+		schema, ok = content[strings.SplitN(mediaType, "/", 2)[0]+"/*"]
+	}
+	if !ok {
+		schema, ok = content["*/*"]
+	}
+	if !ok {
+		return defaultSchema
+	}
+
+	return schema
+}
+
+func (t *Analyzer) validateHeaderParameter(req *http.Request, param *spec.Parameter) error {
+	return t.validateParameterValues(param, req.Header[http.CanonicalHeaderKey(param.Name)])
+}
+
+func (t *Analyzer) validateQueryParameter(req *http.Request, param *spec.Parameter) error {
+	return t.validateParameterValues(param, req.URL.Query()[param.Name])
 }
 
 func (t *Analyzer) validatePathParameter(pathParams denco.Params, param *spec.Parameter) error {
-	var res interface{} = pathParams.Get(param.Name)
-	if param.Type == "integer" {
-		nParam, err := strconv.Atoi(pathParams.Get(param.Name))
-		if err == nil {
-			res = nParam
+	values := []string{pathParams.Get(param.Name)}
+
+	if style, ok := param.Extensions[styleExtension].(map[string]interface{}); ok {
+		name, _ := style["style"].(string)
+		explode, _ := style["explode"].(bool)
+
+		unstyled, ok := unstyleValue(param.Name, name, explode && param.Type == "array", values[0])
+		if !ok {
+			return violation{
+				keyword: "style",
+				message: fmt.Sprintf("%s in path is not a valid %s style value: %q", param.Name, name, values[0]),
+				value:   values[0],
+			}
+		}
+
+		values = unstyled
+	}
+
+	return t.validateParameterValues(param, values)
+}
+
+func (t *Analyzer) validateFormDataParameter(ex *exchange, req *http.Request, param *spec.Parameter) error {
+	form, err := ex.formRequest(req)
+	if err != nil {
+		return err
+	}
+
+	if param.Type != "file" {
+		return t.validateParameterValues(param, form.PostForm[param.Name])
+	}
+
+	data, header, err := form.FormFile(param.Name)
+	if err != nil && param.ParamProps.Required {
+		return violation{
+			keyword: "required",
+			message: fmt.Sprintf("%s in formData is required", param.Name),
 		}
 	}
 
+	res := runtime.File{
+		Data:   data,
+		Header: header,
+	}
+
 	errs := validate.NewParamValidator(param, strfmt.Default).Validate(res)
 	if errs != nil {
 		return errs.AsError()
@@ -209,25 +751,34 @@ func (t *Analyzer) validatePathParameter(pathParams denco.Params, param *spec.Pa
 	return nil
 }
 
-func (t *Analyzer) validateFormDataParameter(req *http.Request, param *spec.Parameter) error {
+// validateParameterValues check the raw values of a parameter, converted into
+// its declared type. No values means that the parameter is missing.
+func (t *Analyzer) validateParameterValues(param *spec.Parameter, values []string) error {
+	if len(values) == 0 {
+		if param.Required {
+			return violation{
+				keyword: "required",
+				message: fmt.Sprintf("%s in %s is required", param.Name, param.In),
+			}
+		}
+		return nil
+	}
 
-	var res interface{}
+	// The empty value of a nullable parameter stands for null.
+	if isNullable(param.Extensions) && param.Type != "array" && values[0] == "" {
+		return nil
+	}
 
-	if param.Type == "file" {
-		data, header, err := req.FormFile(param.Name)
-		if err != nil && param.ParamProps.Required {
-			return fmt.Errorf("validation failure list:\n%s in formData is required", param.Name)
-		}
+	if param.AllowEmptyValue && len(values) == 1 && values[0] == "" {
+		return nil
+	}
 
-		res = runtime.File{
-			Data:   data,
-			Header: header,
-		}
-	} else {
-		res = req.PostFormValue(param.Name)
+	value, err := coerceValues(param.Name, param.In, &param.SimpleSchema, values)
+	if err != nil {
+		return err
 	}
 
-	errs := validate.NewParamValidator(param, strfmt.Default).Validate(res)
+	errs := validate.NewParamValidator(param, strfmt.Default).Validate(value)
 	if errs != nil {
 		return errs.AsError()
 	}
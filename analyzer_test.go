@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -35,7 +36,7 @@ func Test_Analyzer_Analyze_with_request_not_in_specs(t *testing.T) {
 
 	analyzer := NewAnalyzer(specs)
 
-	req, err := http.NewRequest("GET", "invalid/path", nil)
+	req, err := http.NewRequest("GET", "/api/invalid/path", nil)
 	require.NoError(t, err)
 
 	err = analyzer.Analyze(req, nil)
@@ -49,7 +50,7 @@ func Test_Analyzer_Analyze_with_body_parameters(t *testing.T) {
 
 	analyzer := NewAnalyzer(specs)
 
-	req, err := http.NewRequest("POST", "/pet", strings.NewReader(`{
+	req, err := http.NewRequest("POST", "/v2/pet", strings.NewReader(`{
 		"name": "foobar",
 		"photoUrls": ["tutu"]
 	}`))
@@ -78,7 +79,7 @@ func Test_Analyzer_Analyze_with_invalid_body_parameters(t *testing.T) {
 
 	analyzer := NewAnalyzer(specs)
 
-	req, err := http.NewRequest("POST", "/pet", strings.NewReader(`{
+	req, err := http.NewRequest("POST", "/v2/pet", strings.NewReader(`{
 		"name": "foobar"
 	}`))
 	require.NoError(t, err)
@@ -107,12 +108,12 @@ func Test_Analyzer_Analyze_with_invalid_body_format(t *testing.T) {
 
 	analyzer := NewAnalyzer(specs)
 
-	req, err := http.NewRequest("POST", "/pet", strings.NewReader(`not a json`))
+	req, err := http.NewRequest("POST", "/v2/pet", strings.NewReader(`not a json`))
 	require.NoError(t, err)
 
 	res := &http.Response{
-		Status:        http.StatusText(http.StatusOK),
-		StatusCode:    http.StatusOK,
+		Status:        http.StatusText(http.StatusCreated),
+		StatusCode:    http.StatusCreated,
 		Proto:         "HTTP/1.1",
 		ProtoMajor:    1,
 		ProtoMinor:    1,
@@ -124,7 +125,8 @@ func Test_Analyzer_Analyze_with_invalid_body_format(t *testing.T) {
 
 	err = analyzer.Analyze(req, res)
 
-	assert.EqualError(t, err, "invalid character 'o' in literal null (expecting 'u')")
+	assert.EqualError(t, err, "validation failure list:\n"+
+		"failed to parse request body: invalid character 'o' in literal null (expecting 'u')")
 }
 
 func Test_Analyzer_Analyze_with_query_parameters(t *testing.T) {
@@ -133,7 +135,7 @@ func Test_Analyzer_Analyze_with_query_parameters(t *testing.T) {
 
 	analyzer := NewAnalyzer(specs)
 
-	req, err := http.NewRequest("GET", "/pet/findByStatus", nil)
+	req, err := http.NewRequest("GET", "/v2/pet/findByStatus", nil)
 	require.NoError(t, err)
 
 	q := req.URL.Query()
@@ -166,7 +168,7 @@ func Test_Analyzer_Analyze_with_invalid_query_parameters(t *testing.T) {
 
 	analyzer := NewAnalyzer(specs)
 
-	req, err := http.NewRequest("GET", "/pet/findByStatus", nil)
+	req, err := http.NewRequest("GET", "/v2/pet/findByStatus", nil)
 	require.NoError(t, err)
 
 	q := req.URL.Query()
@@ -200,7 +202,7 @@ func Test_Analyzer_Analyze_with_unhandled_method(t *testing.T) {
 
 	analyzer := NewAnalyzer(specs)
 
-	req, err := http.NewRequest("OPTION", "/pet/42", nil)
+	req, err := http.NewRequest("OPTION", "/v2/pet/42", nil)
 	require.NoError(t, err)
 
 	res := &http.Response{
@@ -226,7 +228,7 @@ func Test_Analyzer_Analyze_with_path_parameters(t *testing.T) {
 
 	analyzer := NewAnalyzer(specs)
 
-	req, err := http.NewRequest("GET", "/pet/42", nil)
+	req, err := http.NewRequest("GET", "/v2/pet/42", nil)
 	req.Header.Set("userID", "some-id")
 	require.NoError(t, err)
 
@@ -272,13 +274,13 @@ func Test_Analyzer_Analyze_with_invalid_path_parameters(t *testing.T) {
 
 	analyzer := NewAnalyzer(specs)
 
-	req, err := http.NewRequest("GET", "/pet/not-a-number", nil)
+	req, err := http.NewRequest("GET", "/v2/pet/not-a-number", nil)
 	req.Header.Set("userID", "42")
 	require.NoError(t, err)
 
 	res := &http.Response{
-		Status:        http.StatusText(http.StatusOK),
-		StatusCode:    http.StatusOK,
+		Status:        http.StatusText(http.StatusNotFound),
+		StatusCode:    http.StatusNotFound,
 		Proto:         "HTTP/1.1",
 		ProtoMajor:    1,
 		ProtoMinor:    1,
@@ -291,7 +293,7 @@ func Test_Analyzer_Analyze_with_invalid_path_parameters(t *testing.T) {
 	err = analyzer.Analyze(req, res)
 
 	assert.EqualError(t, err, "validation failure list:\n"+
-		"petId in path must be of type integer: \"string\"")
+		"petId in path is not a valid integer: \"not-a-number\"")
 }
 
 func Test_Analyzer_Analyze_with_formData_file(t *testing.T) {
@@ -312,7 +314,7 @@ func Test_Analyzer_Analyze_with_formData_file(t *testing.T) {
 	err = mp.Close()
 	require.NoError(t, err)
 
-	req, err := http.NewRequest("POST", "/pet/32/uploadImage", &buf)
+	req, err := http.NewRequest("POST", "/v2/pet/32/uploadImage", &buf)
 	require.NoError(t, err)
 
 	req.Header.Set("Content-Type", mp.FormDataContentType())
@@ -354,7 +356,7 @@ func Test_Analyzer_Analyze_with_missing_formData_file(t *testing.T) {
 	err = mp.Close()
 	require.NoError(t, err)
 
-	req, err := http.NewRequest("POST", "/pet/32/uploadImage", &buf)
+	req, err := http.NewRequest("POST", "/v2/pet/32/uploadImage", &buf)
 	require.NoError(t, err)
 
 	req.Header.Set("Content-Type", mp.FormDataContentType())
@@ -365,8 +367,8 @@ func Test_Analyzer_Analyze_with_missing_formData_file(t *testing.T) {
 		Proto:         "HTTP/1.1",
 		ProtoMajor:    1,
 		ProtoMinor:    1,
-		Body:          ioutil.NopCloser(bytes.NewBufferString("")),
-		ContentLength: int64(0),
+		Body:          ioutil.NopCloser(bytes.NewBufferString("{}")),
+		ContentLength: int64(2),
 		Request:       req,
 		Header:        make(http.Header),
 	}
@@ -393,7 +395,7 @@ func Test_Analyzer_Analyze_with_missing_formData_field(t *testing.T) {
 	err = mp.Close()
 	require.NoError(t, err)
 
-	req, err := http.NewRequest("POST", "/pet/32/uploadImage", &buf)
+	req, err := http.NewRequest("POST", "/v2/pet/32/uploadImage", &buf)
 	require.NoError(t, err)
 
 	req.Header.Set("Content-Type", mp.FormDataContentType())
@@ -404,8 +406,8 @@ func Test_Analyzer_Analyze_with_missing_formData_field(t *testing.T) {
 		Proto:         "HTTP/1.1",
 		ProtoMajor:    1,
 		ProtoMinor:    1,
-		Body:          ioutil.NopCloser(bytes.NewBufferString("")),
-		ContentLength: int64(0),
+		Body:          ioutil.NopCloser(bytes.NewBufferString("{}")),
+		ContentLength: int64(2),
 		Request:       req,
 		Header:        make(http.Header),
 	}
@@ -416,13 +418,42 @@ func Test_Analyzer_Analyze_with_missing_formData_field(t *testing.T) {
 		"additionalMetadata in formData is required")
 }
 
+func Test_Analyzer_AnalyzeRequest_keeps_the_form_body(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzerWithOptions(specs, AnalyzerOptions{
+		RejectUnknownParameters: true,
+	})
+
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.PostFormValue("name")
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest("POST", server.URL+"/v2/pet/42", strings.NewReader("name=foo&status=sold"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	err = analyzer.AnalyzeRequest(req)
+	require.NoError(t, err)
+
+	// The request can still be sent after its analysis.
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	assert.Equal(t, "foo", received)
+}
+
 func Test_Analyzer_Analyze_with_header(t *testing.T) {
 	specs, err := NewSpecsFromFile("./dataset/petstore.json")
 	require.NoError(t, err)
 
 	analyzer := NewAnalyzer(specs)
 
-	req, err := http.NewRequest("GET", "/pet/32", nil)
+	req, err := http.NewRequest("GET", "/v2/pet/32", nil)
 	require.NoError(t, err)
 	req.Header.Set("userID", "42")
 
@@ -449,12 +480,12 @@ func Test_Analyzer_Analyze_with_missing_header(t *testing.T) {
 
 	analyzer := NewAnalyzer(specs)
 
-	req, err := http.NewRequest("GET", "/pet/32", nil)
+	req, err := http.NewRequest("GET", "/v2/pet/32", nil)
 	require.NoError(t, err)
 
 	res := &http.Response{
-		Status:        http.StatusText(http.StatusOK),
-		StatusCode:    http.StatusOK,
+		Status:        http.StatusText(http.StatusNotFound),
+		StatusCode:    http.StatusNotFound,
 		Proto:         "HTTP/1.1",
 		ProtoMajor:    1,
 		ProtoMinor:    1,
@@ -476,7 +507,7 @@ func Test_Analyzer_Analyze_with_unrequired_body(t *testing.T) {
 
 	analyzer := NewAnalyzer(specs)
 
-	req, err := http.NewRequest("POST", "/pet", strings.NewReader(`{
+	req, err := http.NewRequest("POST", "/v2/pet", strings.NewReader(`{
 		"name": "foobar",
 		"photoUrls": ["tutu"]
 	}`))
@@ -509,7 +540,7 @@ func Test_Analyzer_Analyze_with_unknown_response_status(t *testing.T) {
 
 	analyzer := NewAnalyzer(specs)
 
-	req, err := http.NewRequest("GET", "/pet/findByStatus", nil)
+	req, err := http.NewRequest("GET", "/v2/pet/findByStatus", nil)
 	require.NoError(t, err)
 
 	q := req.URL.Query()
@@ -543,7 +574,7 @@ func Test_Analyzer_Analyze_with_unmarshalable_response_body(t *testing.T) {
 
 	analyzer := NewAnalyzer(specs)
 
-	req, err := http.NewRequest("GET", "/pet/findByStatus", nil)
+	req, err := http.NewRequest("GET", "/v2/pet/findByStatus", nil)
 	require.NoError(t, err)
 
 	q := req.URL.Query()
@@ -576,7 +607,7 @@ func Test_Analyzer_Analyze_with_invalid_response_body(t *testing.T) {
 
 	analyzer := NewAnalyzer(specs)
 
-	req, err := http.NewRequest("GET", "/pet/findByStatus", nil)
+	req, err := http.NewRequest("GET", "/v2/pet/findByStatus", nil)
 	require.NoError(t, err)
 
 	q := req.URL.Query()
@@ -604,3 +635,857 @@ func Test_Analyzer_Analyze_with_invalid_response_body(t *testing.T) {
 	assert.EqualError(t, err, "validation failure list:\n"+
 		` in body must be of type array: "object"`)
 }
+
+func Test_Analyzer_Analyze_with_openapi3_request_body(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_oas3.yaml")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("POST", "/v1/pets", strings.NewReader(`{
+		"tag": "dog"
+	}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusCreated),
+		StatusCode:    http.StatusCreated,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString("")),
+		ContentLength: int64(0),
+		Request:       req,
+		Header:        make(http.Header),
+	}
+
+	err = analyzer.Analyze(req, res)
+
+	assert.EqualError(t, err, "validation failure list:\n"+
+		".name in body is required")
+}
+
+func Test_Analyzer_AnalyzeRequest_with_openapi3_path_level_parameter(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_oas3.yaml")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/v1/pets/abc", nil)
+	require.NoError(t, err)
+
+	err = analyzer.AnalyzeRequest(req)
+
+	require.IsType(t, ValidationErrors{}, err)
+	validationErr := err.(ValidationErrors)[0]
+	assert.Equal(t, "petId", validationErr.Name)
+	assert.Equal(t, "/paths/~1pets~1{petId}/parameters/0", validationErr.SpecPointer)
+}
+
+func Test_Analyzer_AnalyzeRequest_with_path_level_parameters(t *testing.T) {
+	specs, err := NewSpecsFromRaw([]byte(`{
+		"swagger": "2.0",
+		"info": {"title": "Petstore", "version": "1.0.0"},
+		"paths": {
+			"/pets": {
+				"parameters": [
+					{"name": "limit", "in": "query", "type": "integer"},
+					{"name": "kind", "in": "query", "type": "string", "enum": ["cat"]}
+				],
+				"get": {
+					"parameters": [{"name": "kind", "in": "query", "type": "string"}],
+					"responses": {"200": {"description": "The pets."}}
+				}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/pets?limit=abc&kind=dog", nil)
+	require.NoError(t, err)
+
+	err = analyzer.AnalyzeRequest(req)
+
+	require.IsType(t, ValidationErrors{}, err)
+	require.Len(t, err.(ValidationErrors), 1)
+	validationErr := err.(ValidationErrors)[0]
+	assert.Equal(t, "limit", validationErr.Name)
+	assert.Equal(t, "/paths/~1pets/parameters/0", validationErr.SpecPointer)
+}
+
+func Test_Analyzer_AnalyzeRequest_with_openapi3_external_request_body(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/multi_file_oas3/petstore.yaml")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("POST", "/pets", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	err = analyzer.AnalyzeRequest(req)
+
+	assert.EqualError(t, err, "validation failure list:\n"+
+		".name in body is required")
+}
+
+func Test_Analyzer_Analyze_with_openapi3_media_type_schema(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_oas3.yaml")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("POST", "/v1/pets", strings.NewReader(`{
+		"name": "foobar"
+	}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/vnd.petstore.v2+json")
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusCreated),
+		StatusCode:    http.StatusCreated,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString("")),
+		ContentLength: int64(0),
+		Request:       req,
+		Header:        make(http.Header),
+	}
+
+	err = analyzer.Analyze(req, res)
+
+	assert.EqualError(t, err, "validation failure list:\n"+
+		".tag in body is required")
+}
+
+func Test_Analyzer_Analyze_with_openapi3_response_content(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_oas3.yaml")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/v1/pets/42", nil)
+	require.NoError(t, err)
+
+	body := `{
+		"id": 42,
+		"name": "doggie",
+		"tag": "dog"
+	}`
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+	}
+
+	err = analyzer.Analyze(req, res)
+
+	assert.NoError(t, err)
+}
+
+func Test_Analyzer_Analyze_with_openapi31_schema(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_oas31.yaml")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/pets/42", nil)
+	require.NoError(t, err)
+
+	body := `{
+		"id": 42,
+		"name": "doggie",
+		"kind": "pet",
+		"tag": null,
+		"location": [1.5, 2.5]
+	}`
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+	}
+
+	err = analyzer.Analyze(req, res)
+
+	assert.NoError(t, err)
+}
+
+func Test_Analyzer_Analyze_with_openapi31_unevaluated_property(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_oas31.yaml")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/pets/42", nil)
+	require.NoError(t, err)
+
+	body := `{
+		"id": 42,
+		"name": "doggie",
+		"kind": "pet",
+		"color": "brown"
+	}`
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+	}
+
+	err = analyzer.Analyze(req, res)
+
+	assert.Error(t, err)
+}
+
+func Test_Analyzer_Analyze_with_openapi31_closed_reference(t *testing.T) {
+	specs, err := NewSpecsFromRaw([]byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "pets", "version": "1.0.0"},
+		"paths": {
+			"/pets/{petId}": {
+				"get": {
+					"parameters": [
+						{"name": "petId", "in": "path", "required": true, "schema": {"type": "integer"}}
+					],
+					"responses": {
+						"200": {
+							"description": "pet",
+							"content": {
+								"application/json": {
+									"schema": {"$ref": "#/components/schemas/Pet", "unevaluatedProperties": false}
+								}
+							}
+						}
+					}
+				}
+			}
+		},
+		"components": {
+			"schemas": {
+				"Pet": {
+					"type": "object",
+					"properties": {"name": {"type": "string"}}
+				}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	for body, valid := range map[string]bool{
+		`{"name": "rex"}`:           true,
+		`{"name": "rex", "age": 3}`: false,
+	} {
+		req, err := http.NewRequest("GET", "/pets/42", nil)
+		require.NoError(t, err)
+
+		res := &http.Response{
+			Status:        http.StatusText(http.StatusOK),
+			StatusCode:    http.StatusOK,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+			Header:        http.Header{"Content-Type": []string{"application/json"}},
+		}
+
+		err = analyzer.Analyze(req, res)
+
+		if valid {
+			assert.NoError(t, err, body)
+		} else {
+			assert.Error(t, err, body)
+		}
+	}
+}
+
+func Test_Analyzer_Analyze_with_request_and_response_violations(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/v2/pet/not-a-number", nil)
+	require.NoError(t, err)
+
+	body := `{"name": 42}`
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Header:        make(http.Header),
+	}
+
+	err = analyzer.Analyze(req, res)
+
+	require.IsType(t, ValidationErrors{}, err)
+
+	var messages []string
+	for _, validationErr := range err.(ValidationErrors) {
+		messages = append(messages, string(validationErr.Phase)+": "+validationErr.Message)
+	}
+
+	assert.ElementsMatch(t, []string{
+		"request: userID in header is required",
+		"request: petId in path is not a valid integer: \"not-a-number\"",
+		"response: .photoUrls in body is required",
+		"response: .name in body must be of type string: \"number\"",
+	}, messages)
+}
+
+func Test_Analyzer_AnalyzeRequest(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("POST", "/v2/pet", strings.NewReader(`{
+		"name": "foobar"
+	}`))
+	require.NoError(t, err)
+
+	err = analyzer.AnalyzeRequest(req)
+
+	assert.EqualError(t, err, "validation failure list:\n"+
+		".photoUrls in body is required")
+}
+
+func Test_Analyzer_AnalyzeRequest_with_incoming_request(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req := httptest.NewRequest("POST", "/v2/pet", strings.NewReader(`{
+		"name": "foobar",
+		"photoUrls": []
+	}`))
+
+	err = analyzer.AnalyzeRequest(req)
+	assert.NoError(t, err)
+
+	// The body is still readable by the handler.
+	body, err := ioutil.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "foobar")
+}
+
+func Test_Analyzer_AnalyzeResponse(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	// The request parameters are not checked.
+	req, err := http.NewRequest("GET", "/v2/pet/findByStatus", nil)
+	require.NoError(t, err)
+
+	body := `{}`
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Header:        make(http.Header),
+	}
+
+	err = analyzer.AnalyzeResponse(req, res)
+
+	assert.EqualError(t, err, "validation failure list:\n"+
+		" in body must be of type array: \"object\"")
+}
+
+func Test_Analyzer_AnalyzeResponse_with_no_response(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/v2/pet/findByStatus", nil)
+	require.NoError(t, err)
+
+	err = analyzer.AnalyzeResponse(req, nil)
+
+	assert.EqualError(t, err, "no response defined")
+}
+
+func Test_Analyzer_Analyze_with_response_headers(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/v2/user/login?username=foo&password=bar", nil)
+	require.NoError(t, err)
+
+	body := `"some-token"`
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Header:        make(http.Header),
+	}
+	res.Header.Set("X-Rate-Limit", "100")
+	res.Header.Set("X-Expires-After", "2018-10-03T10:00:00Z")
+
+	err = analyzer.Analyze(req, res)
+
+	assert.NoError(t, err)
+}
+
+func Test_Analyzer_Analyze_with_missing_response_headers(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/v2/user/login?username=foo&password=bar", nil)
+	require.NoError(t, err)
+
+	body := `"some-token"`
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Header:        make(http.Header),
+	}
+
+	err = analyzer.Analyze(req, res)
+
+	// Swagger 2.0 can't declare a response header as required.
+	assert.NoError(t, err)
+}
+
+func Test_Analyzer_Analyze_with_invalid_response_headers(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/v2/user/login?username=foo&password=bar", nil)
+	require.NoError(t, err)
+
+	body := `"some-token"`
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Header:        make(http.Header),
+	}
+	res.Header.Set("X-Rate-Limit", "not-a-number")
+	res.Header.Set("X-Expires-After", "2018-10-03T10:00:00Z")
+
+	err = analyzer.Analyze(req, res)
+
+	require.IsType(t, ValidationErrors{}, err)
+	validationErr := err.(ValidationErrors)[0]
+
+	assert.Equal(t, PhaseResponse, validationErr.Phase)
+	assert.Equal(t, "header", validationErr.In)
+	assert.Equal(t, "X-Rate-Limit", validationErr.Name)
+	assert.Equal(t, "/paths/~1user~1login/get/responses/200/headers/X-Rate-Limit", validationErr.SpecPointer)
+	assert.Equal(t, "type", validationErr.Keyword)
+}
+
+func Test_Analyzer_Analyze_with_default_response(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/v2/user/logout", nil)
+	require.NoError(t, err)
+
+	body := ``
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusInternalServerError),
+		StatusCode:    http.StatusInternalServerError,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+	}
+
+	err = analyzer.Analyze(req, res)
+
+	assert.NoError(t, err)
+}
+
+func Test_Analyzer_Analyze_with_openapi3_default_response(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_oas3.yaml")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/v1/pets/42", nil)
+	require.NoError(t, err)
+
+	body := `{"code": 500}`
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusInternalServerError),
+		StatusCode:    http.StatusInternalServerError,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+	}
+
+	err = analyzer.Analyze(req, res)
+
+	require.IsType(t, ValidationErrors{}, err)
+	validationErr := err.(ValidationErrors)[0]
+
+	assert.Equal(t, "/paths/~1pets~1{petId}/get/responses/default/content", validationErr.SpecPointer)
+	assert.EqualError(t, err, "validation failure list:\n"+
+		".message in body is required")
+}
+
+func Test_Analyzer_Analyze_with_openapi3_response_range(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_oas3.yaml")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/v1/pets/42", nil)
+	require.NoError(t, err)
+
+	body := `{"code": 404, "message": "not found"}`
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusNotFound),
+		StatusCode:    http.StatusNotFound,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+	}
+
+	err = analyzer.Analyze(req, res)
+
+	require.IsType(t, ValidationErrors{}, err)
+	validationErr := err.(ValidationErrors)[0]
+
+	// The range has the priority over the default response.
+	assert.Equal(t, "/paths/~1pets~1{petId}/get/responses/4XX", validationErr.SpecPointer)
+	assert.Equal(t, "schema", validationErr.Keyword)
+}
+
+func Test_Analyzer_AnalyzeRequest_with_openapi3_spec_pointers(t *testing.T) {
+	specs, err := NewSpecsFromRaw([]byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "pets", "version": "1.0.0"},
+		"paths": {
+			"/pets": {
+				"post": {
+					"parameters": [
+						{"name": "session", "in": "cookie", "schema": {"type": "string"}},
+						{"name": "limit", "in": "query", "schema": {"type": "integer"}}
+					],
+					"requestBody": {
+						"content": {
+							"application/json": {
+								"schema": {"type": "object", "required": ["name"]}
+							}
+						}
+					},
+					"responses": {"201": {"description": "created"}}
+				}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("POST", "/pets?limit=abc", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	err = analyzer.AnalyzeRequest(req)
+
+	require.IsType(t, ValidationErrors{}, err)
+	pointers := map[string]string{}
+	for _, validationErr := range err.(ValidationErrors) {
+		pointers[validationErr.In] = validationErr.SpecPointer
+	}
+
+	assert.Equal(t, map[string]string{
+		"query": "/paths/~1pets/post/parameters/1",
+		"body":  "/paths/~1pets/post/requestBody",
+	}, pointers)
+
+	req, err = http.NewRequest("POST", "/pets", strings.NewReader(`name`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "text/plain")
+
+	err = analyzer.AnalyzeRequest(req)
+
+	require.IsType(t, ValidationErrors{}, err)
+	assert.Equal(t, "/paths/~1pets/post/requestBody/content", err.(ValidationErrors)[0].SpecPointer)
+}
+
+func Test_Analyzer_AnalyzeRequest_with_unsupported_content_type(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("POST", "/v2/pet", strings.NewReader(`{
+		"name": "foobar",
+		"photoUrls": []
+	}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "text/plain")
+
+	err = analyzer.AnalyzeRequest(req)
+
+	require.IsType(t, ValidationErrors{}, err)
+	validationErr := err.(ValidationErrors)[0]
+
+	assert.Equal(t, "consumes", validationErr.Keyword)
+	assert.Equal(t, "/paths/~1pet/post/consumes", validationErr.SpecPointer)
+	assert.EqualError(t, err, "validation failure list:\n"+
+		"unsupported media type \"text/plain\", only [application/json application/xml] are allowed")
+}
+
+func Test_Analyzer_AnalyzeResponse_with_content_type(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		err         string
+	}{
+		{
+			name:        "valid",
+			accept:      "application/xml, application/json;q=0.9",
+			contentType: "application/json; charset=utf-8",
+		},
+		{
+			name:        "not produced",
+			contentType: "text/html",
+			err: "validation failure list:\n" +
+				"unsupported media type \"text/html\", only [application/xml application/json] are allowed",
+		},
+		{
+			name:        "not accepted",
+			accept:      "application/xml",
+			contentType: "application/json",
+			err: "validation failure list:\n" +
+				"media type \"application/json\" doesn't match the request Accept header \"application/xml\"",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v2/pet/findByStatus", nil)
+			require.NoError(t, err)
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+
+			res := &http.Response{
+				Status:        http.StatusText(http.StatusOK),
+				StatusCode:    http.StatusOK,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Body:          ioutil.NopCloser(bytes.NewBufferString("[]")),
+				ContentLength: int64(2),
+				Request:       req,
+				Header:        http.Header{"Content-Type": []string{test.contentType}},
+			}
+
+			err = analyzer.AnalyzeResponse(req, res)
+
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func Test_Analyzer_AnalyzeRequest_with_array_body(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("POST", "/v2/user/createWithArray", strings.NewReader(`[{"username": "foo"}, {"username": "bar"}]`))
+	require.NoError(t, err)
+
+	err = analyzer.AnalyzeRequest(req)
+
+	assert.NoError(t, err)
+}
+
+func Test_Analyzer_AnalyzeRequest_with_invalid_array_body(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("POST", "/v2/user/createWithArray", strings.NewReader(`[{"username": "foo"}, {"username": 42}]`))
+	require.NoError(t, err)
+
+	err = analyzer.AnalyzeRequest(req)
+
+	assert.EqualError(t, err, "validation failure list:\n"+
+		".1.username in body must be of type string: \"number\"")
+}
+
+func Test_Analyzer_AnalyzeRequest_with_missing_body(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("POST", "/v2/user/createWithArray", nil)
+	require.NoError(t, err)
+
+	err = analyzer.AnalyzeRequest(req)
+
+	require.IsType(t, ValidationErrors{}, err)
+	validationErr := err.(ValidationErrors)[0]
+
+	assert.Equal(t, "required", validationErr.Keyword)
+	assert.EqualError(t, err, "validation failure list:\n"+
+		"body in body is required")
+}
+
+func Test_Analyzer_AnalyzeRequest_with_missing_body_of_unknown_media_type(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("POST", "/v2/user/createWithArray", nil)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "text/plain")
+
+	err = analyzer.AnalyzeRequest(req)
+
+	assert.EqualError(t, err, "validation failure list:\n"+
+		"body in body is required")
+}
+
+func Test_Analyzer_AnalyzeRequest_with_scalar_body(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_oas3.yaml")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("POST", "/v1/pets", strings.NewReader(`"doggie"`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	err = analyzer.AnalyzeRequest(req)
+
+	assert.EqualError(t, err, "validation failure list:\n"+
+		" in body must be of type object: \"string\"")
+}
+
+func Test_Analyzer_Analyze_with_request_outside_basePath(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/v1/pet/42", nil)
+	require.NoError(t, err)
+
+	err = analyzer.Analyze(req, nil)
+
+	assert.EqualError(t, err, `request path "/v1/pet/42" is outside of the specs basePath "/v2"`)
+}
+
+func Test_routes_trimBasePath(t *testing.T) {
+	r := routes{
+		basePaths: sortBasePaths([]string{"/v1/", "/v1/internal"}),
+	}
+
+	path, err := r.trimBasePath("/v1/internal/pets")
+	assert.NoError(t, err)
+	assert.Equal(t, "/pets", path)
+
+	path, err = r.trimBasePath("/v1/pets")
+	assert.NoError(t, err)
+	assert.Equal(t, "/pets", path)
+
+	path, err = r.trimBasePath("/v1")
+	assert.NoError(t, err)
+	assert.Equal(t, "/", path)
+}
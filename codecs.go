@@ -0,0 +1,237 @@
+package oaichecker
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// Codec decode a raw body into a value which can be checked against the
+// given JSON schema, as the ones produced by encoding/json.
+//
+// The schema can be nil if the specs doesn't define any.
+type Codec func(body []byte, schema *spec.Schema) (interface{}, error)
+
+// defaultCodecs are the codecs used when no codec is registered for a media
+// type. The structured syntax suffixes ("+json", "+xml") are handled apart.
+var defaultCodecs = map[string]Codec{
+	"application/json": JSONCodec,
+	"application/xml":  XMLCodec,
+	"text/xml":         XMLCodec,
+}
+
+// RegisterCodec register the Codec used to decode the bodies of the given
+// media type, overriding the default one if any.
+//
+// The media type can be a range as "text/*" or "*/*", used only if no codec
+// matches exactly.
+func (t *Analyzer) RegisterCodec(mediaType string, codec Codec) {
+	t.codecsLock.Lock()
+	defer t.codecsLock.Unlock()
+
+	if t.codecs == nil {
+		t.codecs = map[string]Codec{}
+	}
+
+	t.codecs[mediaType] = codec
+}
+
+// codecFor retrieve the Codec matching the given Content-Type.
+//
+// The bodies without Content-Type are considered as JSON. If no codec
+// matches, false is returned and the body can't be checked.
+func (t *Analyzer) codecFor(contentType string) (Codec, bool) {
+	if contentType == "" {
+		return JSONCodec, true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	t.codecsLock.RLock()
+	defer t.codecsLock.RUnlock()
+
+	if codec, ok := t.codecs[mediaType]; ok {
+		return codec, true
+	}
+
+	if codec, ok := defaultCodecs[mediaType]; ok {
+		return codec, true
+	}
+
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		return JSONCodec, true
+	case strings.HasSuffix(mediaType, "+xml"):
+		return XMLCodec, true
+	}
+
+	if codec, ok := t.codecs[strings.SplitN(mediaType, "/", 2)[0]+"/*"]; ok {
+		return codec, true
+	}
+
+	codec, ok := t.codecs["*/*"]
+
+	return codec, ok
+}
+
+// JSONCodec is the Codec decoding the JSON bodies.
+func JSONCodec(body []byte, schema *spec.Schema) (interface{}, error) {
+	var res interface{}
+
+	err := json.Unmarshal(body, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// xmlNode is a generic XML element.
+type xmlNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content string     `xml:",chardata"`
+	Nodes   []xmlNode  `xml:",any"`
+}
+
+// XMLCodec is the Codec decoding the XML bodies.
+//
+// The XML elements and attributes are mapped to the schema properties
+// following the "xml" object of the schemas: the names, the attributes and
+// the wrapped arrays. The scalar values are converted into the schema type.
+func XMLCodec(body []byte, schema *spec.Schema) (interface{}, error) {
+	var root xmlNode
+
+	err := xml.NewDecoder(bytes.NewReader(body)).Decode(&root)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeXMLNode(&root, schema), nil
+}
+
+func decodeXMLNode(node *xmlNode, schema *spec.Schema) interface{} {
+	switch {
+	case schema == nil:
+		if len(node.Nodes) == 0 && len(node.Attrs) == 0 {
+			return strings.TrimSpace(node.Content)
+		}
+
+		return decodeXMLObject(node, nil)
+	case schema.Type.Contains("array"):
+		res := []interface{}{}
+		for i := range node.Nodes {
+			res = append(res, decodeXMLNode(&node.Nodes[i], itemsSchema(schema)))
+		}
+
+		return res
+	case schema.Type.Contains("object") || len(schemaProperties(schema)) > 0:
+		return decodeXMLObject(node, schema)
+	case len(schema.Type) == 1:
+		return parseSimpleValue(strings.TrimSpace(node.Content), schema.Type[0])
+	default:
+		return strings.TrimSpace(node.Content)
+	}
+}
+
+// decodeXMLObject map the attributes and the children of node to the schema
+// properties. The unknown ones are kept under their own name.
+func decodeXMLObject(node *xmlNode, schema *spec.Schema) map[string]interface{} {
+	res := map[string]interface{}{}
+	used := map[string]bool{}
+
+	properties := schemaProperties(schema)
+
+	for name, property := range properties {
+		property := property
+		elementName := xmlName(name, &property)
+
+		if property.XML != nil && property.XML.Attribute {
+			for _, attr := range node.Attrs {
+				if attr.Name.Local == elementName {
+					res[name] = decodeXMLNode(&xmlNode{Content: attr.Value}, &property)
+				}
+			}
+			used["@"+elementName] = true
+			continue
+		}
+
+		if !property.Type.Contains("array") {
+			for i := range node.Nodes {
+				if node.Nodes[i].XMLName.Local == elementName {
+					res[name] = decodeXMLNode(&node.Nodes[i], &property)
+				}
+			}
+			used[elementName] = true
+			continue
+		}
+
+		if property.XML != nil && property.XML.Wrapped {
+			for i := range node.Nodes {
+				if node.Nodes[i].XMLName.Local == elementName {
+					res[name] = decodeXMLNode(&node.Nodes[i], &property)
+				}
+			}
+			used[elementName] = true
+			continue
+		}
+
+		// The items of the unwrapped arrays are repeated directly inside the
+		// object, under the items name if any.
+		itemName := elementName
+		if itemSchema := itemsSchema(&property); itemSchema != nil && itemSchema.XML != nil && itemSchema.XML.Name != "" {
+			itemName = itemSchema.XML.Name
+		}
+
+		items := []interface{}{}
+		for i := range node.Nodes {
+			if node.Nodes[i].XMLName.Local == itemName {
+				items = append(items, decodeXMLNode(&node.Nodes[i], itemsSchema(&property)))
+			}
+		}
+
+		if len(items) > 0 {
+			res[name] = items
+		}
+		used[itemName] = true
+	}
+
+	for _, attr := range node.Attrs {
+		if !used["@"+attr.Name.Local] && attr.Name.Space != "xmlns" && attr.Name.Local != "xmlns" {
+			res[attr.Name.Local] = attr.Value
+		}
+	}
+
+	for i := range node.Nodes {
+		if !used[node.Nodes[i].XMLName.Local] {
+			res[node.Nodes[i].XMLName.Local] = decodeXMLNode(&node.Nodes[i], nil)
+		}
+	}
+
+	return res
+}
+
+// xmlName return the name of the XML element or attribute of a property.
+func xmlName(name string, schema *spec.Schema) string {
+	if schema.XML != nil && schema.XML.Name != "" {
+		return schema.XML.Name
+	}
+
+	return name
+}
+
+// itemsSchema return the schema of the items of an array schema, if unique.
+func itemsSchema(schema *spec.Schema) *spec.Schema {
+	if schema.Items == nil {
+		return nil
+	}
+
+	return schema.Items.Schema
+}
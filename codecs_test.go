@@ -0,0 +1,137 @@
+package oaichecker
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_XMLCodec(t *testing.T) {
+	var schema spec.Schema
+	err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"id": {"type": "integer", "xml": {"attribute": true}},
+			"name": {"type": "string", "xml": {"name": "fullName"}},
+			"photoUrls": {
+				"type": "array",
+				"xml": {"name": "photoUrl", "wrapped": true},
+				"items": {"type": "string"}
+			},
+			"tags": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"xml": {"name": "tag"},
+					"properties": {
+						"available": {"type": "boolean"}
+					}
+				}
+			}
+		}
+	}`), &schema)
+	require.NoError(t, err)
+
+	res, err := XMLCodec([]byte(`<Pet id="42">
+		<fullName>doggie</fullName>
+		<photoUrl>
+			<photoUrls>some-url</photoUrls>
+		</photoUrl>
+		<tag><available>true</available></tag>
+		<tag><available>false</available></tag>
+		<unknown>foobar</unknown>
+	</Pet>`), &schema)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"id":        int64(42),
+		"name":      "doggie",
+		"photoUrls": []interface{}{"some-url"},
+		"tags": []interface{}{
+			map[string]interface{}{"available": true},
+			map[string]interface{}{"available": false},
+		},
+		"unknown": "foobar",
+	}, res)
+}
+
+func Test_XMLCodec_with_invalid_xml(t *testing.T) {
+	res, err := XMLCodec([]byte(`<Pet>`), nil)
+
+	assert.Nil(t, res)
+	assert.Error(t, err)
+}
+
+func Test_Analyzer_Analyze_with_xml_body(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("POST", "/v2/pet", strings.NewReader(`<Pet>
+		<photoUrl><photoUrls>some-url</photoUrls></photoUrl>
+		<status>unknown</status>
+	</Pet>`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/xml")
+
+	err = analyzer.AnalyzeRequest(req)
+
+	require.IsType(t, ValidationErrors{}, err)
+
+	var messages []string
+	for _, validationErr := range err.(ValidationErrors) {
+		messages = append(messages, validationErr.Message)
+	}
+
+	assert.ElementsMatch(t, []string{
+		".name in body is required",
+		".status in body should be one of [available pending sold]",
+	}, messages)
+}
+
+func Test_Analyzer_RegisterCodec(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+	analyzer.RegisterCodec("text/*", func(body []byte, schema *spec.Schema) (interface{}, error) {
+		return []interface{}{string(body)}, nil
+	})
+
+	req, err := http.NewRequest("GET", "/v2/pet/findByStatus?status=sold", nil)
+	require.NoError(t, err)
+
+	body := "some text"
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Header:        http.Header{"Content-Type": []string{"text/plain"}},
+	}
+
+	err = analyzer.AnalyzeResponse(req, res)
+
+	// The body is decoded as an array of strings instead of Pets.
+	require.IsType(t, ValidationErrors{}, err)
+
+	var keywords []string
+	for _, validationErr := range err.(ValidationErrors) {
+		keywords = append(keywords, validationErr.Keyword)
+	}
+
+	assert.ElementsMatch(t, []string{"produces", "type"}, keywords)
+}
@@ -0,0 +1,228 @@
+package oaichecker
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/jsonpointer"
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/validate"
+)
+
+// validateBody check a decoded body against its schema. The objects having a
+// discriminator are also checked against the concrete schema selected by
+// their discriminator value.
+func (t *Analyzer) validateBody(ex *exchange, input interface{}, schema *spec.Schema, phase Phase) error {
+	errs := []error{validate.AgainstSchema(schema, input, strfmt.Default)}
+	errs = append(errs, forbiddenProperties(input, schema, phase, "")...)
+	errs = append(errs, t.discriminatedErrors(ex, input, schema, phase, "", false)...)
+
+	err := joinErrors(errs...)
+	if err == nil {
+		return nil
+	}
+
+	// The concrete schemas include their base schema through "allOf", whose
+	// violations are then reported twice.
+	var res []error
+	seen := map[string]bool{}
+	for _, sub := range flattenErrors(err) {
+		if !seen[sub.Error()] {
+			seen[sub.Error()] = true
+			res = append(res, sub)
+		}
+	}
+
+	return joinErrors(res...)
+}
+
+// discriminatedErrors walk the given body value along its schema and check
+// the objects having a discriminator against their concrete schema.
+//
+// resolved is set when s is the concrete schema of value or one of its
+// bases, whose discriminator is already handled.
+func (t *Analyzer) discriminatedErrors(ex *exchange, value interface{}, s *spec.Schema, phase Phase, path string, resolved bool) []error {
+	if s == nil {
+		return nil
+	}
+
+	var res []error
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if s.Discriminator != "" && !resolved {
+			concrete, err := ex.discriminatedSchema(value, s, path)
+			if err != nil {
+				return []error{err}
+			}
+
+			if concrete != nil {
+				concrete = t.bodySchema(ex.routes, concrete, phase)
+
+				res = append(res, validate.NewSchemaValidator(concrete, nil, path, strfmt.Default).Validate(value).AsError())
+				res = append(res, forbiddenProperties(value, concrete, phase, path)...)
+
+				return append(res, t.discriminatedErrors(ex, value, concrete, phase, path, true)...)
+			}
+		}
+
+		for i := range s.AllOf {
+			res = append(res, t.discriminatedErrors(ex, value, &s.AllOf[i], phase, path, resolved)...)
+		}
+
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			sub, ok := value[name]
+			if !ok {
+				continue
+			}
+
+			property := s.Properties[name]
+			res = append(res, t.discriminatedErrors(ex, sub, &property, phase, path+"."+name, false)...)
+		}
+	case []interface{}:
+		if s.Items == nil || s.Items.Schema == nil {
+			return nil
+		}
+
+		for i, item := range value {
+			res = append(res, t.discriminatedErrors(ex, item, s.Items.Schema, phase, path+"."+strconv.Itoa(i), false)...)
+		}
+	}
+
+	return res
+}
+
+// discriminatedSchema return the definition selected by the discriminator
+// value of the given object, or nil if the value is missing.
+//
+// The accepted values are the ones of the discriminator mapping, completed
+// with the implicit ones when loading the specs.
+func (t *exchange) discriminatedSchema(value map[string]interface{}, s *spec.Schema, path string) (*spec.Schema, error) {
+	name, ok := value[s.Discriminator].(string)
+	if !ok {
+		return nil, nil
+	}
+
+	mapping, _ := s.Extensions[discriminatorMappingExtension].(map[string]interface{})
+	if ref, ok := mapping[name].(string); ok {
+		definition := unescapePointerToken(ref[strings.LastIndex(ref, "/")+1:])
+		if res, ok := t.routes.definitions[definition]; ok {
+			return res, nil
+		}
+	}
+
+	propertyPath := path + "." + s.Discriminator
+
+	return nil, violation{
+		keyword: "discriminator",
+		message: fmt.Sprintf("%s in body is not a valid discriminator value: %q", propertyPath, name),
+		pointer: payloadPointer("body", "", propertyPath),
+		value:   name,
+	}
+}
+
+// completeDiscriminatorMappings add to the discriminator mapping of each
+// definition of the raw Swagger 2.0 doc its implicit values: the name of the
+// definition itself and the names of the definitions including it through
+// "allOf", directly or not.
+func completeDiscriminatorMappings(doc map[string]interface{}) {
+	definitions := asMap(doc["definitions"])
+
+	for name, rawDefinition := range definitions {
+		definition := asMap(rawDefinition)
+		if _, ok := definition["discriminator"].(string); !ok {
+			continue
+		}
+
+		mapping := asMap(definition[discriminatorMappingExtension])
+		if mapping == nil {
+			mapping = map[string]interface{}{}
+		}
+
+		for _, subtype := range append([]string{name}, subtypes(definitions, name)...) {
+			if _, ok := mapping[subtype]; !ok {
+				mapping[subtype] = "#/definitions/" + jsonpointer.Escape(subtype)
+			}
+		}
+
+		definition[discriminatorMappingExtension] = mapping
+	}
+}
+
+// subtypes list the names of the raw definitions including the base one
+// through "allOf", directly or not.
+func subtypes(definitions map[string]interface{}, base string) []string {
+	var res []string
+
+	bases := map[string]bool{base: true}
+	for changed := true; changed; {
+		changed = false
+
+		for name, definition := range definitions {
+			if bases[name] {
+				continue
+			}
+
+			for _, sub := range asSlice(asMap(definition)["allOf"]) {
+				ref, _ := asMap(sub)["$ref"].(string)
+				if strings.HasPrefix(ref, "#/definitions/") && bases[unescapePointerToken(strings.TrimPrefix(ref, "#/definitions/"))] {
+					bases[name] = true
+					res = append(res, name)
+					changed = true
+
+					break
+				}
+			}
+		}
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+// discriminatorMapping return the mapping of an OpenAPI 3 discriminator,
+// with its values turned into definitions references, completed by the
+// schemas listed in the "oneOf" and "anyOf" of the raw schema s.
+func discriminatorMapping(discriminator map[string]interface{}, s map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+
+	for _, key := range []string{"oneOf", "anyOf"} {
+		for _, sub := range asSlice(s[key]) {
+			if ref, ok := asMap(sub)["$ref"].(string); ok {
+				ref = definitionRef(ref)
+				res[unescapePointerToken(ref[strings.LastIndex(ref, "/")+1:])] = ref
+			}
+		}
+	}
+
+	for value, target := range asMap(discriminator["mapping"]) {
+		if ref, ok := target.(string); ok {
+			res[value] = definitionRef(ref)
+		}
+	}
+
+	return res
+}
+
+// definitionRef turn a discriminator mapping target, either a schema name or
+// a reference, into a definition reference.
+func definitionRef(target string) string {
+	switch {
+	case strings.HasPrefix(target, "#/components/schemas/"):
+		return "#/definitions/" + strings.TrimPrefix(target, "#/components/schemas/")
+	case strings.HasPrefix(target, "#"):
+		return target
+	default:
+		return "#/definitions/" + jsonpointer.Escape(target)
+	}
+}
@@ -0,0 +1,225 @@
+package oaichecker
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discriminatorSpecs = []byte(`{
+	"swagger": "2.0",
+	"info": {"title": "pets", "version": "1.0.0"},
+	"paths": {
+		"/pets": {
+			"post": {
+				"parameters": [
+					{
+						"name": "pets",
+						"in": "body",
+						"required": true,
+						"schema": {"type": "array", "items": {"$ref": "#/definitions/Pet"}}
+					}
+				],
+				"responses": {"201": {"description": "created"}}
+			}
+		}
+	},
+	"definitions": {
+		"Pet": {
+			"type": "object",
+			"discriminator": "petType",
+			"required": ["name", "petType"],
+			"properties": {
+				"name": {"type": "string"},
+				"petType": {"type": "string"}
+			}
+		},
+		"Cat": {
+			"allOf": [
+				{"$ref": "#/definitions/Pet"},
+				{
+					"type": "object",
+					"required": ["huntingSkill"],
+					"properties": {
+						"huntingSkill": {"type": "string", "enum": ["lazy", "aggressive"]}
+					}
+				}
+			]
+		},
+		"User": {
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"}
+			}
+		}
+	}
+}`)
+
+var discriminatorMappingSpecs = []byte(`{
+	"openapi": "3.0.0",
+	"info": {"title": "pets", "version": "1.0.0"},
+	"paths": {
+		"/pets": {
+			"post": {
+				"requestBody": {
+					"required": true,
+					"content": {
+						"application/json": {
+							"schema": {"$ref": "#/components/schemas/Pet"}
+						}
+					}
+				},
+				"responses": {"201": {"description": "created"}}
+			}
+		}
+	},
+	"components": {
+		"schemas": {
+			"Pet": {
+				"type": "object",
+				"required": ["petType"],
+				"properties": {
+					"petType": {"type": "string"}
+				},
+				"discriminator": {
+					"propertyName": "petType",
+					"mapping": {"cat": "#/components/schemas/Cat"}
+				}
+			},
+			"Cat": {
+				"allOf": [
+					{"$ref": "#/components/schemas/Pet"},
+					{
+						"type": "object",
+						"required": ["huntingSkill"],
+						"properties": {
+							"huntingSkill": {"type": "string"}
+						}
+					}
+				]
+			}
+		}
+	}
+}`)
+
+func Test_Analyzer_AnalyzeRequest_with_discriminator(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   []byte
+		options AnalyzerOptions
+		body    string
+		err     string
+	}{
+		{
+			name:  "valid concrete definition",
+			specs: discriminatorSpecs,
+			body:  `[{"name": "foo", "petType": "Cat", "huntingSkill": "lazy"}]`,
+		},
+		{
+			name:  "invalid concrete definition",
+			specs: discriminatorSpecs,
+			body:  `[{"name": "foo", "petType": "Cat", "huntingSkill": "sleepy"}]`,
+			err:   "validation failure list:\n.0.huntingSkill in body should be one of [lazy aggressive]",
+		},
+		{
+			name:  "base violations reported once",
+			specs: discriminatorSpecs,
+			body:  `[{"petType": "Cat", "huntingSkill": "lazy"}]`,
+			err:   "validation failure list:\n.0.name in body is required",
+		},
+		{
+			name:  "unknown discriminator value",
+			specs: discriminatorSpecs,
+			body:  `[{"name": "foo", "petType": "Dog"}]`,
+			err:   "validation failure list:\n.0.petType in body is not a valid discriminator value: \"Dog\"",
+		},
+		{
+			name:  "base discriminator value",
+			specs: discriminatorSpecs,
+			body:  `[{"name": "foo", "petType": "Pet"}]`,
+		},
+		{
+			name:  "unrelated definition name",
+			specs: discriminatorSpecs,
+			body:  `[{"name": "foo", "petType": "User"}]`,
+			err:   "validation failure list:\n.0.petType in body is not a valid discriminator value: \"User\"",
+		},
+		{
+			name:    "concrete properties with unknown properties rejected",
+			specs:   discriminatorSpecs,
+			options: AnalyzerOptions{RejectUnknownRequestProperties: true},
+			body:    `[{"name": "foo", "petType": "Cat", "huntingSkill": "lazy"}]`,
+		},
+		{
+			name:  "mapped discriminator value",
+			specs: discriminatorMappingSpecs,
+			body:  `{"petType": "cat"}`,
+			err:   "validation failure list:\n.huntingSkill in body is required",
+		},
+		{
+			name:  "unmapped definition name",
+			specs: discriminatorMappingSpecs,
+			body:  `{"petType": "Cat", "huntingSkill": "lazy"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			specs, err := NewSpecsFromRaw(test.specs)
+			require.NoError(t, err)
+
+			analyzer := NewAnalyzerWithOptions(specs, test.options)
+
+			req, err := http.NewRequest("POST", "/pets", bytes.NewBufferString(test.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			err = analyzer.AnalyzeRequest(req)
+
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func Test_Analyzer_AnalyzeRequest_with_discriminator_error_details(t *testing.T) {
+	specs, err := NewSpecsFromRaw(discriminatorSpecs)
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("POST", "/pets", bytes.NewBufferString(`[{"name": "foo", "petType": "Dog"}]`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	err = analyzer.AnalyzeRequest(req)
+
+	require.IsType(t, ValidationErrors{}, err)
+	require.Len(t, err, 1)
+
+	assert.Equal(t, "discriminator", err.(ValidationErrors)[0].Keyword)
+	assert.Equal(t, "/0/petType", err.(ValidationErrors)[0].Pointer)
+	assert.Equal(t, "Dog", err.(ValidationErrors)[0].Value)
+}
+
+func Test_discriminatorMapping(t *testing.T) {
+	mapping := discriminatorMapping(map[string]interface{}{
+		"propertyName": "petType",
+		"mapping":      map[string]interface{}{"cat": "Cat"},
+	}, map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"$ref": "#/components/schemas/Dog"},
+		},
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"cat": "#/definitions/Cat",
+		"Dog": "#/definitions/Dog",
+	}, mapping)
+}
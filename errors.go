@@ -0,0 +1,307 @@
+package oaichecker
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/spec"
+)
+
+// Phase is the part of the exchange in which a ValidationError occurred.
+type Phase string
+
+const (
+	// PhaseRequest is used for the errors found inside the http.Request.
+	PhaseRequest Phase = "request"
+	// PhaseResponse is used for the errors found inside the http.Response.
+	PhaseResponse Phase = "response"
+)
+
+// validationKeywords match the go-openapi/errors codes with the JSON Schema
+// keyword they report.
+var validationKeywords = map[int32]string{
+	errors.InvalidTypeCode:           "type",
+	errors.RequiredFailCode:          "required",
+	errors.TooLongFailCode:           "maxLength",
+	errors.TooShortFailCode:          "minLength",
+	errors.PatternFailCode:           "pattern",
+	errors.EnumFailCode:              "enum",
+	errors.MultipleOfFailCode:        "multipleOf",
+	errors.MaxFailCode:               "maximum",
+	errors.MinFailCode:               "minimum",
+	errors.UniqueFailCode:            "uniqueItems",
+	errors.MaxItemsFailCode:          "maxItems",
+	errors.MinItemsFailCode:          "minItems",
+	errors.NoAdditionalItemsCode:     "additionalItems",
+	errors.TooFewPropertiesCode:      "minProperties",
+	errors.TooManyPropertiesCode:     "maxProperties",
+	errors.UnallowedPropertyCode:     "additionalProperties",
+	errors.FailedAllPatternPropsCode: "patternProperties",
+}
+
+// ValidationError is a single violation of the specs found by the Analyzer.
+//
+// It can be retrieved from the error returned by Analyzer.Analyze with
+// errors.As.
+type ValidationError struct {
+	// Method, Path and OperationID identify the operation. Path is the path
+	// template as written inside the specs, without the basePath.
+	Method      string
+	Path        string
+	OperationID string
+
+	Phase Phase
+
+	// In is the location of the faulty element: "path", "header", "body",
+	// "query", "formData" or "status". Name is the parameter or the header
+	// name, empty for the other response elements.
+	In   string
+	Name string
+
+	// Pointer is the JSON pointer to the faulty value inside the parameter
+	// or the body, and SpecPointer the JSON pointer to the specs element
+	// defining it, inside the original OpenAPI 3 document if any. The paths
+	// are the ones of the routing table, prefixed for the merged specs.
+	Pointer     string
+	SpecPointer string
+
+	// Keyword is the violated keyword, "required" or "maxLength" for
+	// example. It is empty when the value can't be checked at all, as for
+	// an unparsable body.
+	Keyword string
+	Value   interface{}
+
+	Message string
+	Err     error
+}
+
+func (t *ValidationError) Error() string {
+	return t.Message
+}
+
+// Unwrap return the underlying error, usually an *errors.Validation from
+// go-openapi/errors.
+func (t *ValidationError) Unwrap() error {
+	return t.Err
+}
+
+// ValidationErrors is the list of violations found by the Analyzer.
+type ValidationErrors []*ValidationError
+
+func (t ValidationErrors) Error() string {
+	messages := make([]string, 0, len(t))
+	for _, err := range t {
+		messages = append(messages, err.Message)
+	}
+
+	return "validation failure list:\n" + strings.Join(messages, "\n")
+}
+
+// As allows errors.As to retrieve the first ValidationError of the list.
+func (t ValidationErrors) As(target interface{}) bool {
+	res, ok := target.(**ValidationError)
+	if !ok || len(t) == 0 {
+		return false
+	}
+
+	*res = t[0]
+
+	return true
+}
+
+// violation is an error detected by the Analyzer itself, outside of the
+// go-openapi/validate validators.
+type violation struct {
+	keyword string
+	message string
+
+	pointer string
+	value   interface{}
+}
+
+func (t violation) Error() string {
+	return t.message
+}
+
+// exchange collect the violations found while analyzing a request/response
+// pair against an operation.
+type exchange struct {
+	method    string
+	path      string
+	operation *spec.Operation
+
+	// parameters are the parameters applying to the operation, the path
+	// level ones included.
+	parameters []operationParameter
+
+	// consumes and produces are the media types of the operation, completed
+	// by the global ones.
+	consumes []string
+	produces []string
+
+	// accept is the Accept header of the request.
+	accept string
+
+	// apiKeys are the parameters used by the security definitions, indexed
+	// by location.
+	apiKeys map[string][]string
+
+	// routes is the state of the Analyzer used for the exchange, which
+	// stays the same even if the specs are reloaded meanwhile.
+	routes *routes
+
+	// form is a copy of the request with its form parsed, see formRequest.
+	form *http.Request
+
+	errs ValidationErrors
+}
+
+// add record the violations contained inside err, if any.
+func (t *exchange) add(phase Phase, in string, name string, specPointer string, err error) {
+	if err == nil {
+		return
+	}
+
+	for _, sub := range flattenErrors(err) {
+		res := ValidationError{
+			Method:      t.method,
+			Path:        t.path,
+			OperationID: t.operation.ID,
+			Phase:       phase,
+			In:          in,
+			Name:        name,
+			SpecPointer: specPointer,
+			Message:     sub.Error(),
+			Err:         sub,
+		}
+
+		switch sub := sub.(type) {
+		case *errors.Validation:
+			res.Keyword = validationKeywords[sub.Code()]
+			res.Value = sub.Value
+			res.Pointer = payloadPointer(in, name, sub.Name)
+
+			// The forbidden properties are reported on their parent object.
+			if property, ok := sub.Value.(string); ok && sub.Code() == errors.UnallowedPropertyCode {
+				res.Pointer += "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(property)
+			}
+		case violation:
+			res.Keyword = sub.keyword
+			res.Value = sub.value
+			res.Pointer = sub.pointer
+		}
+
+		t.errs = append(t.errs, &res)
+	}
+}
+
+// err return the violations found as an error, or nil if there is none.
+func (t *exchange) err() error {
+	if len(t.errs) == 0 {
+		return nil
+	}
+
+	return t.errs
+}
+
+// specPointer return the JSON pointer to an element of the operation inside
+// the specs.
+func (t *exchange) specPointer(tokens ...string) string {
+	return t.pathItemPointer(append([]string{strings.ToLower(t.method)}, tokens...)...)
+}
+
+// pathItemPointer return the JSON pointer to the given element of the path
+// item.
+func (t *exchange) pathItemPointer(tokens ...string) string {
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+
+	res := "/paths/" + escaper.Replace(t.path)
+	for _, token := range tokens {
+		res += "/" + escaper.Replace(token)
+	}
+
+	return res
+}
+
+// mediaTypesPointer return the JSON pointer to the "consumes" or "produces"
+// list applying to the operation, either its own or the global one.
+//
+// For OpenAPI 3, the media types are the keys of the "content" of the request
+// body or of the response found at responsePointer.
+func (t *exchange) mediaTypesPointer(key string, responsePointer string) string {
+	if t.routes.openAPI3 {
+		if key == "produces" {
+			return responsePointer + "/content"
+		}
+
+		return t.specPointer("requestBody", "content")
+	}
+
+	mediaTypes := t.operation.Consumes
+	if key == "produces" {
+		mediaTypes = t.operation.Produces
+	}
+
+	if len(mediaTypes) == 0 {
+		return "/" + key
+	}
+
+	return t.specPointer(key)
+}
+
+// flattenErrors split the list built by go-openapi/validate into its
+// elements. The nested lists are kept as is as their message gives the
+// context of their elements, as for "oneOf".
+func flattenErrors(err error) []error {
+	composite, ok := err.(*errors.CompositeError)
+	if !ok {
+		return []error{err}
+	}
+
+	return composite.Errors
+}
+
+// joinErrors merge the given errors, the nil ones excluded, into a single
+// list. It returns nil if there is no error.
+func joinErrors(errs ...error) error {
+	var res []error
+	for _, err := range errs {
+		if err != nil {
+			res = append(res, flattenErrors(err)...)
+		}
+	}
+
+	if len(res) == 0 {
+		return nil
+	}
+
+	return errors.CompositeValidationError(res...)
+}
+
+// payloadPointer convert the dotted name given by go-openapi/validate into a
+// JSON pointer relative to the parameter or body value.
+//
+// The body names start with a dot (".tags.0.name") while the other ones
+// start with the parameter name ("status.0").
+func payloadPointer(in string, name string, validationName string) string {
+	var path string
+	if in == "body" {
+		path = strings.TrimPrefix(validationName, ".")
+	} else {
+		path = strings.TrimPrefix(strings.TrimPrefix(validationName, name), ".")
+	}
+
+	if path == "" {
+		return ""
+	}
+
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+
+	var res string
+	for _, token := range strings.Split(path, ".") {
+		res += "/" + escaper.Replace(token)
+	}
+
+	return res
+}
@@ -0,0 +1,32 @@
+//go:build go1.13
+// +build go1.13
+
+package oaichecker
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ValidationErrors_As_through_wrapping(t *testing.T) {
+	errs := ValidationErrors{
+		{Message: "first"},
+		{Message: "second"},
+	}
+
+	err := fmt.Errorf("wrapped: %w", errs)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.EqualError(t, validationErr, "first")
+
+	var list ValidationErrors
+	require.True(t, errors.As(err, &list))
+	assert.Len(t, list, 2)
+
+	assert.EqualError(t, errs, "validation failure list:\nfirst\nsecond")
+}
@@ -0,0 +1,119 @@
+package oaichecker
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ValidationError_with_body_parameter(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("POST", "/v2/pet", strings.NewReader(`{
+		"name": "foobar"
+	}`))
+	require.NoError(t, err)
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusCreated),
+		StatusCode:    http.StatusCreated,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString("")),
+		ContentLength: int64(0),
+		Request:       req,
+		Header:        make(http.Header),
+	}
+
+	err = analyzer.Analyze(req, res)
+
+	require.IsType(t, ValidationErrors{}, err)
+	validationErr := err.(ValidationErrors)[0]
+
+	assert.Equal(t, "POST", validationErr.Method)
+	assert.Equal(t, "/pet", validationErr.Path)
+	assert.Equal(t, "addPet", validationErr.OperationID)
+	assert.Equal(t, PhaseRequest, validationErr.Phase)
+	assert.Equal(t, "body", validationErr.In)
+	assert.Equal(t, "body", validationErr.Name)
+	assert.Equal(t, "/photoUrls", validationErr.Pointer)
+	assert.Equal(t, "/paths/~1pet/post/parameters/0", validationErr.SpecPointer)
+	assert.Equal(t, "required", validationErr.Keyword)
+	assert.EqualError(t, validationErr, ".photoUrls in body is required")
+}
+
+func Test_ValidationError_with_query_parameter(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/v2/pet/findByStatus?status=invalid-enum-value", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString("[]")),
+		ContentLength: int64(2),
+		Request:       req,
+		Header:        make(http.Header),
+	}
+
+	err = analyzer.Analyze(req, res)
+
+	require.IsType(t, ValidationErrors{}, err)
+	validationErr := err.(ValidationErrors)[0]
+
+	assert.Equal(t, "findPetsByStatus", validationErr.OperationID)
+	assert.Equal(t, "query", validationErr.In)
+	assert.Equal(t, "status", validationErr.Name)
+	assert.Equal(t, "/0", validationErr.Pointer)
+	assert.Equal(t, "/paths/~1pet~1findByStatus/get/parameters/0", validationErr.SpecPointer)
+	assert.Equal(t, "enum", validationErr.Keyword)
+	assert.Equal(t, "invalid-enum-value", validationErr.Value)
+}
+
+func Test_ValidationError_with_undefined_response_status(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/v2/pet/findByStatus?status=sold", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusTeapot),
+		StatusCode:    http.StatusTeapot,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString("")),
+		ContentLength: int64(0),
+		Request:       req,
+		Header:        make(http.Header),
+	}
+
+	err = analyzer.Analyze(req, res)
+
+	require.IsType(t, ValidationErrors{}, err)
+	validationErr := err.(ValidationErrors)[0]
+
+	assert.Equal(t, PhaseResponse, validationErr.Phase)
+	assert.Equal(t, "status", validationErr.In)
+	assert.Equal(t, "/paths/~1pet~1findByStatus/get/responses", validationErr.SpecPointer)
+	assert.Equal(t, "responses", validationErr.Keyword)
+}
@@ -42,7 +42,7 @@ func Example_post_request_validation() {
 	}
 
 	// Make a request with a required field missing.
-	_, err = client.Post("http://petstore.swagger.io/pet", "application/json", strings.NewReader(`{
+	_, err = client.Post("http://petstore.swagger.io/v2/pet", "application/json", strings.NewReader(`{
 		"photoUrls": ["some-url"]
 	}`))
 	if err != nil {
@@ -50,6 +50,6 @@ func Example_post_request_validation() {
 	}
 
 	// Output:
-	// Post http://petstore.swagger.io/pet: validation failure list:
+	// Post http://petstore.swagger.io/v2/pet: validation failure list:
 	// .name in body is required
 }
@@ -0,0 +1,74 @@
+package oaichecker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-openapi/swag"
+)
+
+// fileLoader is a documentLoader reading the documents from the file system,
+// or fetching them if their location is an URL.
+func fileLoader(location string) ([]byte, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return swag.LoadFromFileOrHTTP(location)
+	}
+
+	return ioutil.ReadFile(filepath.FromSlash(location))
+}
+
+// httpLoader return a documentLoader fetching the documents with the given
+// client.
+//
+// If cacheDir is set, each fetched document is saved inside it and the saved
+// copy is used instead of the network from then on.
+func httpLoader(client *http.Client, cacheDir string) documentLoader {
+	return func(location string) ([]byte, error) {
+		var cachePath string
+
+		if cacheDir != "" {
+			sum := sha256.Sum256([]byte(location))
+			cachePath = filepath.Join(cacheDir, hex.EncodeToString(sum[:]))
+
+			cached, err := ioutil.ReadFile(cachePath)
+			if err == nil {
+				return cached, nil
+			}
+		}
+
+		res, err := client.Get(location)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch %s: %s", location, res.Status)
+		}
+
+		body, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		if cachePath != "" {
+			err = os.MkdirAll(cacheDir, 0755)
+			if err != nil {
+				return nil, err
+			}
+
+			err = ioutil.WriteFile(cachePath, body, 0644)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return body, nil
+	}
+}
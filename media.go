@@ -0,0 +1,70 @@
+package oaichecker
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// matchMediaType check if the given media type, parameters excluded, is
+// matched by one of the media ranges ("application/json", "text/*" or "*/*").
+func matchMediaType(mediaType string, ranges []string) bool {
+	for _, mediaRange := range ranges {
+		parsed, _, err := mime.ParseMediaType(mediaRange)
+		if err != nil {
+			continue
+		}
+
+		if mediaRangeContains(parsed, mediaType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// acceptsMediaType check if the given media type is acceptable according to
+// the value of an Accept header. The media ranges with a zero quality are
+// excluded.
+func acceptsMediaType(accept string, mediaType string) bool {
+	for _, rawRange := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(rawRange))
+		if err != nil {
+			continue
+		}
+
+		if q, ok := params["q"]; ok {
+			quality, err := strconv.ParseFloat(q, 64)
+			if err == nil && quality == 0 {
+				continue
+			}
+		}
+
+		if mediaRangeContains(mediaRange, mediaType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func mediaRangeContains(mediaRange string, mediaType string) bool {
+	switch {
+	case mediaRange == "*/*" || mediaRange == mediaType:
+		return true
+	case strings.HasSuffix(mediaRange, "/*"):
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*"))
+	default:
+		return false
+	}
+}
+
+// mediaTypesFor return the media types of an operation, or the global ones if
+// it declares none, in their declaration order.
+func mediaTypesFor(operationTypes []string, globalTypes []string) []string {
+	if len(operationTypes) > 0 {
+		return operationTypes
+	}
+
+	return globalTypes
+}
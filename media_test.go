@@ -0,0 +1,31 @@
+package oaichecker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_matchMediaType(t *testing.T) {
+	assert.True(t, matchMediaType("application/json", []string{"application/xml", "application/json"}))
+	assert.True(t, matchMediaType("multipart/form-data", []string{"multipart/form-data; boundary=foo"}))
+	assert.True(t, matchMediaType("text/plain", []string{"text/*"}))
+	assert.True(t, matchMediaType("image/png", []string{"*/*"}))
+	assert.False(t, matchMediaType("text/plain", []string{"application/json"}))
+	assert.False(t, matchMediaType("text/plain", nil))
+}
+
+func Test_acceptsMediaType(t *testing.T) {
+	assert.True(t, acceptsMediaType("application/json", "application/json"))
+	assert.True(t, acceptsMediaType("text/html, application/*;q=0.8", "application/json"))
+	assert.True(t, acceptsMediaType("*/*", "application/json"))
+	assert.False(t, acceptsMediaType("application/xml", "application/json"))
+	assert.False(t, acceptsMediaType("application/json;q=0", "application/json"))
+}
+
+func Test_mediaTypesFor(t *testing.T) {
+	global := []string{"application/xml", "application/json"}
+
+	assert.Equal(t, []string{"text/plain", "application/json"}, mediaTypesFor([]string{"text/plain", "application/json"}, global))
+	assert.Equal(t, global, mediaTypesFor(nil, global))
+}
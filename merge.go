@@ -0,0 +1,349 @@
+package oaichecker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-openapi/jsonpointer"
+	"github.com/go-openapi/loads"
+	"github.com/go-openapi/spec"
+)
+
+var pathParamRegexp = regexp.MustCompile(`\{[^}]*\}`)
+
+// Mount is some Specs served under a path prefix, see MergeSpecs.
+type Mount struct {
+	Prefix string
+	Specs  *Specs
+}
+
+// MergeSpecs merge several Specs into a single one, allowing a single
+// Analyzer or Transport to validate the traffic of several APIs.
+//
+// Each operation is routed under the prefix of its Mount followed by the
+// basePath of its Specs. An error is returned if two operations end up with
+// the same method and path.
+//
+// The global media types and security requirements of each Specs are copied
+// into its operations. The definitions are only kept for the recursive
+// schemas and the discriminators: a definition whose name is already used by
+// a different one is renamed, along with its references, so that each Specs
+// keeps its own. In case of duplicate security definitions, the first one is
+// used.
+func MergeSpecs(mounts ...Mount) (*Specs, error) {
+	var hosts, schemes []string
+	version := swaggerVersion
+
+	merged := spec.Swagger{
+		SwaggerProps: spec.SwaggerProps{
+			Swagger: swaggerVersion,
+			Info: &spec.Info{
+				InfoProps: spec.InfoProps{
+					Title:   "Merged specs",
+					Version: "1.0.0",
+				},
+			},
+			Paths: &spec.Paths{
+				Paths: map[string]spec.PathItem{},
+			},
+			Definitions:         spec.Definitions{},
+			SecurityDefinitions: spec.SecurityDefinitions{},
+		},
+	}
+
+	seen := map[string]bool{}
+
+	for _, mount := range mounts {
+		if mount.Specs == nil {
+			return nil, errors.New("specs is nil")
+		}
+
+		if mount.Specs.version != swaggerVersion {
+			version = mount.Specs.version
+		}
+
+		hosts = append(hosts, mount.Specs.hosts...)
+		schemes = append(schemes, mount.Specs.schemes...)
+
+		swagger, err := ownDefinitions(mount.Specs.document.Spec(), merged.Definitions)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, definition := range swagger.Definitions {
+			merged.Definitions[name] = definition
+		}
+
+		for name, scheme := range swagger.SecurityDefinitions {
+			if _, ok := merged.SecurityDefinitions[name]; !ok {
+				merged.SecurityDefinitions[name] = scheme
+			}
+		}
+
+		if swagger.Paths == nil {
+			continue
+		}
+
+		for _, prefix := range mountPrefixes(mount) {
+			for path, item := range swagger.Paths.Paths {
+				err := mergePathItem(merged.Paths.Paths, prefix+path, item, swagger, seen)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	rawSpec, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := loads.Analyzed(rawSpec, "")
+	if err != nil {
+		return nil, err
+	}
+
+	err = expandContents(document)
+	if err != nil {
+		return nil, err
+	}
+
+	// The merged document only keeps the definitions still referenced,
+	// and can then be checked as is.
+	specs := Specs{
+		document:           document,
+		validationDocument: document,
+		version:            version,
+		hosts:              hosts,
+		schemes:            schemes,
+	}
+
+	return &specs, nil
+}
+
+// mountPrefixes list the distinct prefixes under which the paths of a Mount
+// are routed, one per basePath.
+func mountPrefixes(mount Mount) []string {
+	basePaths := mount.Specs.basePaths
+	if len(basePaths) == 0 {
+		basePaths = []string{""}
+	}
+
+	var res []string
+	seen := map[string]bool{}
+
+	for _, basePath := range basePaths {
+		prefix := strings.TrimSuffix(mount.Prefix, "/") + strings.TrimSuffix(basePath, "/")
+		if !seen[prefix] {
+			seen[prefix] = true
+			res = append(res, prefix)
+		}
+	}
+
+	return res
+}
+
+// mergePathItem add the operations of item to the path item found at path.
+//
+// The path level parameters and the global properties of root are moved
+// inside the operations as the merged path item can come from several specs.
+func mergePathItem(paths map[string]spec.PathItem, path string, item spec.PathItem, root *spec.Swagger, seen map[string]bool) error {
+	merged := paths[path]
+
+	for method, operation := range pathItemOperations(item) {
+		// The operations differing only by their path parameters names
+		// have the same route.
+		key := method + " " + pathParamRegexp.ReplaceAllString(path, "{}")
+		if seen[key] {
+			return fmt.Errorf("operation %s %s defined by several specs", method, path)
+		}
+		seen[key] = true
+
+		res := *operation
+		res.Parameters = mergeParameters(item.Parameters, operation.Parameters)
+
+		if len(res.Consumes) == 0 {
+			res.Consumes = root.Consumes
+		}
+
+		if len(res.Produces) == 0 {
+			res.Produces = root.Produces
+		}
+
+		// An empty security list disables the global requirements.
+		if res.Security == nil {
+			res.Security = root.Security
+		}
+
+		switch method {
+		case http.MethodGet:
+			merged.Get = &res
+		case http.MethodPut:
+			merged.Put = &res
+		case http.MethodPost:
+			merged.Post = &res
+		case http.MethodDelete:
+			merged.Delete = &res
+		case http.MethodOptions:
+			merged.Options = &res
+		case http.MethodHead:
+			merged.Head = &res
+		case http.MethodPatch:
+			merged.Patch = &res
+		}
+	}
+
+	paths[path] = merged
+
+	return nil
+}
+
+// mergeParameters return the operation parameters completed by the path
+// level ones they don't override.
+func mergeParameters(pathParams []spec.Parameter, operationParams []spec.Parameter) []spec.Parameter {
+	var res []spec.Parameter
+
+	for _, pathParam := range pathParams {
+		overridden := false
+		for _, operationParam := range operationParams {
+			if operationParam.Name == pathParam.Name && operationParam.In == pathParam.In {
+				overridden = true
+			}
+		}
+
+		if !overridden {
+			res = append(res, pathParam)
+		}
+	}
+
+	return append(res, operationParams...)
+}
+
+// ownDefinitions return a copy of swagger where the definitions whose name is
+// already used by a different definition of merged are renamed, along with
+// their references and discriminator mappings.
+func ownDefinitions(swagger *spec.Swagger, merged spec.Definitions) (*spec.Swagger, error) {
+	renames := map[string]string{}
+
+	taken := func(name string) bool {
+		_, inMerged := merged[name]
+		_, inSwagger := swagger.Definitions[name]
+
+		return inMerged || inSwagger
+	}
+
+	for name, definition := range swagger.Definitions {
+		existing, ok := merged[name]
+		if !ok || sameJSON(existing, definition) {
+			continue
+		}
+
+		for i := 1; ; i++ {
+			newName := fmt.Sprintf("%s%d", name, i)
+			if !taken(newName) && !renamedTo(renames, newName) {
+				renames[name] = newName
+				break
+			}
+		}
+	}
+
+	if len(renames) == 0 {
+		return swagger, nil
+	}
+
+	rawSwagger, err := json.Marshal(swagger)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	err = json.Unmarshal(rawSwagger, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	renameReferences(doc, renames)
+
+	definitions := asMap(doc["definitions"])
+	for name, newName := range renames {
+		definitions[newName] = definitions[name]
+		delete(definitions, name)
+	}
+
+	rawSwagger, err = json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var res spec.Swagger
+	err = json.Unmarshal(rawSwagger, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// sameJSON check if a and b have the same JSON representation.
+func sameJSON(a interface{}, b interface{}) bool {
+	rawA, errA := json.Marshal(a)
+	rawB, errB := json.Marshal(b)
+
+	return errA == nil && errB == nil && bytes.Equal(rawA, rawB)
+}
+
+// renamedTo check if a definition is already renamed to name.
+func renamedTo(renames map[string]string, name string) bool {
+	for _, newName := range renames {
+		if newName == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// renameReferences update the references to the renamed definitions found
+// inside value, the discriminator mappings included.
+func renameReferences(value interface{}, renames map[string]string) {
+	rename := func(ref interface{}) interface{} {
+		s, ok := ref.(string)
+		if !ok || !strings.HasPrefix(s, "#/definitions/") {
+			return ref
+		}
+
+		newName, ok := renames[unescapePointerToken(strings.TrimPrefix(s, "#/definitions/"))]
+		if !ok {
+			return ref
+		}
+
+		return "#/definitions/" + jsonpointer.Escape(newName)
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, sub := range value {
+			switch key {
+			case "$ref":
+				value[key] = rename(sub)
+			case discriminatorMappingExtension:
+				mapping := asMap(sub)
+				for name, target := range mapping {
+					mapping[name] = rename(target)
+				}
+			default:
+				renameReferences(sub, renames)
+			}
+		}
+	case []interface{}:
+		for _, sub := range value {
+			renameReferences(sub, renames)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package oaichecker
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MergeSpecs(t *testing.T) {
+	storeSpecs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	minimalSpecs, err := NewSpecsFromFile("./dataset/petstore_minimal.json")
+	require.NoError(t, err)
+
+	specs, err := MergeSpecs(
+		Mount{Prefix: "/store", Specs: storeSpecs},
+		Mount{Prefix: "/minimal/", Specs: minimalSpecs},
+	)
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/minimal/api/pets", nil)
+	require.NoError(t, err)
+
+	// nolint: goconst
+	body := `[]`
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Header:        make(http.Header),
+	}
+
+	err = analyzer.Analyze(req, res)
+	assert.NoError(t, err)
+
+	req, err = http.NewRequest("POST", "/store/v2/pet", strings.NewReader(`{
+		"name": "foobar"
+	}`))
+	require.NoError(t, err)
+
+	res = &http.Response{
+		Status:        http.StatusText(http.StatusCreated),
+		StatusCode:    http.StatusCreated,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString("")),
+		ContentLength: int64(0),
+		Request:       req,
+		Header:        make(http.Header),
+	}
+
+	err = analyzer.Analyze(req, res)
+	assert.EqualError(t, err, "validation failure list:\n"+
+		".photoUrls in body is required")
+}
+
+func Test_MergeSpecs_with_path_level_parameters(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_oas3.yaml")
+	require.NoError(t, err)
+
+	merged, err := MergeSpecs(Mount{Specs: specs})
+	require.NoError(t, err)
+
+	operation, ok := merged.document.Analyzer.OperationFor("GET", "/v1/pets/{petId}")
+	require.True(t, ok)
+	require.Len(t, operation.Parameters, 1)
+	assert.Equal(t, "petId", operation.Parameters[0].Name)
+}
+
+func Test_MergeSpecs_with_global_properties(t *testing.T) {
+	specs, err := NewSpecsFromRaw([]byte(`{
+		"swagger": "2.0",
+		"info": {"title": "notes", "version": "1.0.0"},
+		"consumes": ["application/json"],
+		"produces": ["application/json"],
+		"securityDefinitions": {
+			"key": {"type": "apiKey", "in": "header", "name": "X-API-Key"}
+		},
+		"security": [{"key": []}],
+		"paths": {
+			"/notes": {
+				"post": {
+					"parameters": [
+						{"name": "note", "in": "body", "schema": {"type": "object"}}
+					],
+					"responses": {"201": {"description": "created"}}
+				}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	merged, err := MergeSpecs(Mount{Prefix: "/notes-api", Specs: specs})
+	require.NoError(t, err)
+
+	operation, ok := merged.document.Analyzer.OperationFor("POST", "/notes-api/notes")
+	require.True(t, ok)
+	assert.Equal(t, []string{"application/json"}, operation.Consumes)
+	assert.Equal(t, []string{"application/json"}, operation.Produces)
+	assert.Equal(t, []map[string][]string{{"key": {}}}, operation.Security)
+
+	analyzer := NewAnalyzerWithOptions(merged, AnalyzerOptions{
+		RejectUnknownHeaders: true,
+	})
+
+	req, err := http.NewRequest("POST", "/notes-api/notes", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("X-API-Key", "secret")
+
+	err = analyzer.AnalyzeRequest(req)
+
+	assert.EqualError(t, err, "validation failure list:\n"+
+		`unsupported media type "text/plain", only [application/json] are allowed`)
+}
+
+func Test_MergeSpecs_with_conflicting_operations(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_minimal.json")
+	require.NoError(t, err)
+
+	merged, err := MergeSpecs(
+		Mount{Specs: specs},
+		Mount{Prefix: "/", Specs: specs},
+	)
+
+	assert.Nil(t, merged)
+	assert.EqualError(t, err, "operation GET /api/pets defined by several specs")
+}
+
+func Test_MergeSpecs_with_same_definition_names(t *testing.T) {
+	catSpecs := func(property string) *Specs {
+		specs, err := NewSpecsFromRaw([]byte(`{
+			"swagger": "2.0",
+			"info": {"title": "pets", "version": "1.0.0"},
+			"paths": {
+				"/pets": {
+					"post": {
+						"parameters": [
+							{"name": "pet", "in": "body", "required": true, "schema": {"$ref": "#/definitions/Pet"}}
+						],
+						"responses": {"201": {"description": "created"}}
+					}
+				}
+			},
+			"definitions": {
+				"Pet": {
+					"type": "object",
+					"discriminator": "petType",
+					"required": ["petType"],
+					"properties": {"petType": {"type": "string"}}
+				},
+				"Cat": {
+					"allOf": [
+						{"$ref": "#/definitions/Pet"},
+						{"type": "object", "required": ["` + property + `"]}
+					]
+				}
+			}
+		}`))
+		require.NoError(t, err)
+
+		return specs
+	}
+
+	specs, err := MergeSpecs(
+		Mount{Prefix: "/a", Specs: catSpecs("hunting")},
+		Mount{Prefix: "/b", Specs: catSpecs("name")},
+	)
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	for prefix, body := range map[string]string{
+		"/a": `{"petType": "Cat", "hunting": "lazy"}`,
+		"/b": `{"petType": "Cat", "name": "Tom"}`,
+	} {
+		req, err := http.NewRequest("POST", prefix+"/pets", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		assert.NoError(t, analyzer.AnalyzeRequest(req), prefix)
+	}
+
+	req, err := http.NewRequest("POST", "/b/pets", strings.NewReader(`{"petType": "Cat", "hunting": "lazy"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	err = analyzer.AnalyzeRequest(req)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ".name in body is required")
+}
+
+func Test_MergeSpecs_with_nil_specs(t *testing.T) {
+	merged, err := MergeSpecs(Mount{Prefix: "/foo"})
+
+	assert.Nil(t, merged)
+	assert.EqualError(t, err, "specs is nil")
+}
@@ -0,0 +1,772 @@
+package oaichecker
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	swaggerVersion = "2.0"
+
+	// contentExtension is the vendor extension keeping, for the request
+	// bodies and the responses converted from OpenAPI 3, the schema of each
+	// media type declared inside their "content" map.
+	contentExtension = "x-oaichecker-content"
+
+	// requiredExtension mark the response headers declared as required by
+	// OpenAPI 3, Swagger 2.0 having no such property.
+	requiredExtension = "x-oaichecker-required"
+
+	// rangesExtension is the vendor extension keeping the OpenAPI 3
+	// responses declared for a range of status codes ("2XX"), unknown to
+	// Swagger 2.0.
+	rangesExtension = "x-oaichecker-ranges"
+
+	// discriminatorMappingExtension keep the mapping of the OpenAPI 3
+	// discriminators, the Swagger 2.0 "discriminator" being only the
+	// property name.
+	discriminatorMappingExtension = "x-discriminator-mapping"
+
+	// pointerExtension keep the JSON pointer to the OpenAPI 3 declaration of
+	// a converted parameter, relatively to its operation or path item.
+	pointerExtension = "x-oaichecker-pointer"
+
+	// styleExtension keep the OpenAPI 3 "label" and "matrix" styles of the
+	// path parameters, whose values are prefixed, along with their explode
+	// flag.
+	styleExtension = "x-oaichecker-style"
+
+	// maxRefHops limit the number of references followed in order to resolve
+	// a component, protecting against the cyclic references.
+	maxRefHops = 32
+)
+
+var operationMethods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+var simpleSchemaKeywords = []string{
+	"format", "default", "enum", "maximum", "exclusiveMaximum", "minimum",
+	"exclusiveMinimum", "maxLength", "minLength", "pattern", "maxItems",
+	"minItems", "uniqueItems", "multipleOf",
+}
+
+var subSchemaKeywords = []string{
+	"not", "additionalProperties", "additionalItems", "items", "contains",
+	"if", "then", "else", "propertyNames", "unevaluatedProperties",
+	"unevaluatedItems", "allOf", "anyOf", "oneOf", "prefixItems",
+}
+
+var subSchemaMapKeywords = []string{
+	"properties", "patternProperties", "definitions", "$defs", "dependentSchemas",
+}
+
+// isOpenAPI3 check if the given document follows the OpenAPI 3
+// specifications.
+func isOpenAPI3(doc map[string]interface{}) bool {
+	version, ok := doc["openapi"].(string)
+
+	return ok && strings.HasPrefix(version, "3.")
+}
+
+// oas3Converter convert an OpenAPI 3 document into its Swagger 2.0
+// equivalent, the only version understood by go-openapi.
+//
+// The references to the components are resolved inline, except for the
+// schemas which are moved into the definitions.
+type oas3Converter struct {
+	components map[string]interface{}
+
+	// jsonSchema2020 is set for the OpenAPI 3.1 documents, whose schemas
+	// follow the JSON Schema 2020-12 semantics.
+	jsonSchema2020 bool
+}
+
+func convertOpenAPI3(doc map[string]interface{}) map[string]interface{} {
+	version, _ := doc["openapi"].(string)
+
+	c := oas3Converter{
+		components:     asMap(doc["components"]),
+		jsonSchema2020: strings.HasPrefix(version, "3.1"),
+	}
+
+	if c.jsonSchema2020 {
+		c.hoistDefs(doc)
+	}
+
+	res := map[string]interface{}{
+		"swagger": swaggerVersion,
+		"info":    doc["info"],
+		"paths":   c.convertPaths(asMap(doc["paths"])),
+	}
+
+	for key, value := range doc {
+		if key == "tags" || key == "externalDocs" || key == "security" || strings.HasPrefix(key, "x-") {
+			res[key] = value
+		}
+	}
+
+	// Swagger 2.0 allows only one server.
+	servers := asSlice(doc["servers"])
+	if len(servers) > 0 {
+		u, err := serverURL(asMap(servers[0]))
+		if err == nil {
+			if u.Host != "" {
+				res["host"] = u.Host
+			}
+
+			if u.Scheme != "" {
+				res["schemes"] = []interface{}{u.Scheme}
+			}
+
+			if u.Path != "" && u.Path != "/" {
+				res["basePath"] = u.Path
+			}
+		}
+	}
+
+	schemas := asMap(c.components["schemas"])
+	if len(schemas) > 0 {
+		definitions := map[string]interface{}{}
+		for name, schema := range schemas {
+			definitions[name] = c.convertSchema(asMap(schema))
+		}
+
+		res["definitions"] = definitions
+	}
+
+	securitySchemes := asMap(c.components["securitySchemes"])
+	if len(securitySchemes) > 0 {
+		securityDefinitions := map[string]interface{}{}
+		for name, scheme := range securitySchemes {
+			securityDefinitions[name] = convertSecurityScheme(c.resolve(asMap(scheme)))
+		}
+
+		res["securityDefinitions"] = securityDefinitions
+	}
+
+	return res
+}
+
+// oauth2Flows match the OpenAPI 3 OAuth2 flows with their Swagger 2.0 name,
+// by order of preference, Swagger 2.0 allowing only one flow per scheme.
+var oauth2Flows = []struct {
+	oas3    string
+	swagger string
+}{
+	{"implicit", "implicit"},
+	{"password", "password"},
+	{"clientCredentials", "application"},
+	{"authorizationCode", "accessCode"},
+}
+
+// convertSecurityScheme convert an OpenAPI 3 security scheme into a Swagger
+// 2.0 security definition.
+//
+// The schemes unknown to Swagger 2.0 are converted into the API key sent
+// with the same header: the bearer and OpenID Connect tokens are sent inside
+// the Authorization header, the cookie API keys inside the Cookie header.
+func convertSecurityScheme(scheme map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+	if description, ok := scheme["description"]; ok {
+		res["description"] = description
+	}
+
+	switch scheme["type"] {
+	case "apiKey":
+		res["type"] = "apiKey"
+		res["in"] = scheme["in"]
+		res["name"] = scheme["name"]
+
+		if scheme["in"] == "cookie" {
+			res["in"] = "header"
+			res["name"] = "Cookie"
+		}
+	case "http":
+		if scheme["scheme"] == "basic" {
+			res["type"] = "basic"
+			break
+		}
+
+		res["type"] = "apiKey"
+		res["in"] = "header"
+		res["name"] = "Authorization"
+	case "oauth2":
+		res["type"] = "oauth2"
+
+		flows := asMap(scheme["flows"])
+		for _, flow := range oauth2Flows {
+			props, ok := flows[flow.oas3].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			res["flow"] = flow.swagger
+			res["scopes"] = props["scopes"]
+			for _, key := range []string{"authorizationUrl", "tokenUrl"} {
+				if value, ok := props[key]; ok {
+					res[key] = value
+				}
+			}
+			break
+		}
+	default:
+		res["type"] = "apiKey"
+		res["in"] = "header"
+		res["name"] = "Authorization"
+	}
+
+	return res
+}
+
+// serverURL return the URL of an OpenAPI 3 server object, with its variables
+// replaced by their default value.
+func serverURL(server map[string]interface{}) (*url.URL, error) {
+	rawURL, _ := server["url"].(string)
+
+	for name, variable := range asMap(server["variables"]) {
+		value, _ := asMap(variable)["default"].(string)
+		rawURL = strings.Replace(rawURL, "{"+name+"}", value, -1)
+	}
+
+	return url.Parse(rawURL)
+}
+
+// serverURLs list the URLs of all the servers of an OpenAPI 3 document.
+func serverURLs(doc map[string]interface{}) []*url.URL {
+	var res []*url.URL
+
+	for _, server := range asSlice(doc["servers"]) {
+		u, err := serverURL(asMap(server))
+		if err == nil {
+			res = append(res, u)
+		}
+	}
+
+	return res
+}
+
+func (t *oas3Converter) convertPaths(paths map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+
+	for path, rawItem := range paths {
+		item := asMap(rawItem)
+		resItem := map[string]interface{}{}
+
+		params := t.convertParameters(asSlice(item["parameters"]))
+		if len(params) > 0 {
+			resItem["parameters"] = params
+		}
+
+		for _, method := range operationMethods {
+			if operation, ok := item[method]; ok {
+				resItem[method] = t.convertOperation(asMap(operation))
+			}
+		}
+
+		res[path] = resItem
+	}
+
+	return res
+}
+
+func (t *oas3Converter) convertOperation(operation map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+
+	for key, value := range operation {
+		switch {
+		case key == "tags", key == "summary", key == "description", key == "externalDocs",
+			key == "operationId", key == "deprecated", key == "security", strings.HasPrefix(key, "x-"):
+			res[key] = value
+		}
+	}
+
+	params := t.convertParameters(asSlice(operation["parameters"]))
+
+	if body, ok := operation["requestBody"]; ok {
+		bodyParams, consumes := t.convertRequestBody(t.resolve(asMap(body)))
+
+		params = append(params, bodyParams...)
+		if len(consumes) > 0 {
+			res["consumes"] = consumes
+		}
+	}
+
+	if len(params) > 0 {
+		res["parameters"] = params
+	}
+
+	responses, produces := t.convertResponses(asMap(operation["responses"]))
+
+	res["responses"] = responses
+	if len(produces) > 0 {
+		res["produces"] = produces
+	}
+
+	return res
+}
+
+func (t *oas3Converter) convertParameters(params []interface{}) []interface{} {
+	var res []interface{}
+
+	for i, rawParam := range params {
+		param := t.resolve(asMap(rawParam))
+
+		// Swagger 2.0 doesn't handle the cookies.
+		if param["in"] == "cookie" {
+			continue
+		}
+
+		converted := t.convertParameter(param)
+		converted[pointerExtension] = "/parameters/" + strconv.Itoa(i)
+
+		res = append(res, converted)
+	}
+
+	return res
+}
+
+func (t *oas3Converter) convertParameter(param map[string]interface{}) map[string]interface{} {
+	in, _ := param["in"].(string)
+
+	res := t.simpleSchema(t.resolve(asMap(param["schema"])))
+	res["name"] = param["name"]
+	res["in"] = in
+
+	for key, value := range param {
+		switch {
+		case key == "description", key == "required", key == "allowEmptyValue", strings.HasPrefix(key, "x-"):
+			res[key] = value
+		}
+	}
+
+	if res["type"] == "array" {
+		res["collectionFormat"] = collectionFormat(in, param)
+	}
+
+	if style, _ := param["style"].(string); in == "path" && (style == "label" || style == "matrix") {
+		explode, _ := param["explode"].(bool)
+		res[styleExtension] = map[string]interface{}{"style": style, "explode": explode}
+	}
+
+	return res
+}
+
+// collectionFormat translate the OpenAPI 3 style/explode serialization of an
+// array parameter into its Swagger 2.0 collectionFormat.
+func collectionFormat(in string, param map[string]interface{}) string {
+	style, ok := param["style"].(string)
+	if !ok {
+		style = "simple"
+		if in == "query" {
+			style = "form"
+		}
+	}
+
+	explode, ok := param["explode"].(bool)
+	if !ok {
+		explode = style == "form"
+	}
+
+	// The exploded "label" and "matrix" values are split when removing their
+	// prefix, see unstyleValue.
+	switch {
+	case explode && (style == "form" || style == "spaceDelimited" || style == "pipeDelimited") &&
+		(in == "query" || in == "formData"):
+		return "multi"
+	case style == "spaceDelimited":
+		return "ssv"
+	case style == "pipeDelimited":
+		return "pipes"
+	default:
+		return "csv"
+	}
+}
+
+// simpleSchema translate a schema into the subset of JSON schema allowed for
+// the Swagger 2.0 parameters, headers and items.
+func (t *oas3Converter) simpleSchema(schema map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{
+		"type": "string",
+	}
+
+	if t.jsonSchema2020 && schema != nil {
+		walkSchema(schema, t.downgradeSchema)
+	}
+
+	// The objects can't be described in a simple schema and are kept as
+	// strings.
+	switch schemaType := schema["type"].(type) {
+	case string:
+		if schemaType != "object" {
+			res["type"] = schemaType
+		}
+	case []interface{}:
+		for i := len(schemaType) - 1; i >= 0; i-- {
+			switch schemaType[i] {
+			case "null":
+				res["x-nullable"] = true
+			case "object":
+			default:
+				res["type"] = schemaType[i]
+			}
+		}
+	}
+
+	for _, key := range simpleSchemaKeywords {
+		if value, ok := schema[key]; ok {
+			res[key] = value
+		}
+	}
+
+	if res["type"] == "array" {
+		res["items"] = t.simpleSchema(t.resolve(asMap(schema["items"])))
+	}
+
+	if schema["nullable"] == true || schema["x-nullable"] == true {
+		res["x-nullable"] = true
+	}
+
+	return res
+}
+
+func (t *oas3Converter) convertRequestBody(body map[string]interface{}) ([]interface{}, []interface{}) {
+	content := asMap(body["content"])
+	mediaTypes := sortedKeys(content)
+
+	consumes := make([]interface{}, 0, len(mediaTypes))
+	for _, mediaType := range mediaTypes {
+		consumes = append(consumes, mediaType)
+	}
+
+	primary := primaryMediaType(mediaTypes)
+	if primary == "" {
+		return nil, consumes
+	}
+
+	schema := asMap(asMap(content[primary])["schema"])
+
+	if isFormMediaType(primary) {
+		return t.convertFormProperties(t.resolve(schema), primary), consumes
+	}
+
+	param := map[string]interface{}{
+		"name":           "body",
+		"in":             "body",
+		"required":       body["required"] == true,
+		"schema":         t.convertSchema(schema),
+		contentExtension: t.convertContent(content),
+		pointerExtension: "/requestBody",
+	}
+
+	if description, ok := body["description"]; ok {
+		param["description"] = description
+	}
+
+	return []interface{}{param}, consumes
+}
+
+// convertFormProperties turn the properties of a form body schema into
+// formData parameters.
+func (t *oas3Converter) convertFormProperties(schema map[string]interface{}, mediaType string) []interface{} {
+	required := map[string]bool{}
+	for _, name := range asSlice(schema["required"]) {
+		if name, ok := name.(string); ok {
+			required[name] = true
+		}
+	}
+
+	properties := asMap(schema["properties"])
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+
+	res := make([]interface{}, 0, len(properties))
+	for _, name := range sortedKeys(properties) {
+		property := t.resolve(asMap(properties[name]))
+
+		param := t.simpleSchema(property)
+		param["name"] = name
+		param["in"] = "formData"
+		param["required"] = required[name]
+		param[pointerExtension] = "/requestBody/content/" + escaper.Replace(mediaType) + "/schema/properties/" + escaper.Replace(name)
+
+		switch {
+		case param["type"] == "array":
+			param["collectionFormat"] = "multi"
+		case param["type"] == "string" && mediaType == "multipart/form-data" &&
+			(property["format"] == "binary" || property["format"] == "base64"):
+			param["type"] = "file"
+			delete(param, "format")
+		}
+
+		res = append(res, param)
+	}
+
+	return res
+}
+
+func (t *oas3Converter) convertResponses(responses map[string]interface{}) (map[string]interface{}, []interface{}) {
+	res := map[string]interface{}{}
+	ranges := map[string]interface{}{}
+	produces := []interface{}{}
+	seen := map[string]bool{}
+
+	for code, rawResponse := range responses {
+		if strings.HasPrefix(code, "x-") {
+			res[code] = rawResponse
+			continue
+		}
+
+		// Only the explicit status codes and "default" exist in Swagger 2.0,
+		// the ranges are kept aside.
+		_, err := strconv.Atoi(code)
+		isRange := isStatusRange(code)
+		if err != nil && code != "default" && !isRange {
+			continue
+		}
+
+		response := t.resolve(asMap(rawResponse))
+		mediaTypes := sortedKeys(asMap(response["content"]))
+
+		for _, mediaType := range mediaTypes {
+			if !seen[mediaType] {
+				seen[mediaType] = true
+				produces = append(produces, mediaType)
+			}
+		}
+
+		if isRange {
+			ranges[strings.ToUpper(code)] = t.convertResponse(response)
+		} else {
+			res[code] = t.convertResponse(response)
+		}
+	}
+
+	if len(ranges) > 0 {
+		res[rangesExtension] = ranges
+	}
+
+	return res, produces
+}
+
+// isStatusRange check if the given response code is an OpenAPI 3 range of
+// status codes, as "2XX".
+func isStatusRange(code string) bool {
+	return len(code) == 3 && code[0] >= '1' && code[0] <= '5' && strings.EqualFold(code[1:], "XX")
+}
+
+func (t *oas3Converter) convertResponse(response map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{
+		"description": "",
+	}
+
+	if description, ok := response["description"]; ok {
+		res["description"] = description
+	}
+
+	headers := asMap(response["headers"])
+	if len(headers) > 0 {
+		res["headers"] = t.convertHeaders(headers)
+	}
+
+	content := asMap(response["content"])
+
+	primary := primaryMediaType(sortedKeys(content))
+	if schema, ok := asMap(content[primary])["schema"]; ok {
+		res["schema"] = t.convertSchema(asMap(schema))
+		res[contentExtension] = t.convertContent(content)
+	}
+
+	return res
+}
+
+func (t *oas3Converter) convertHeaders(headers map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+
+	for name, rawHeader := range headers {
+		header := t.resolve(asMap(rawHeader))
+
+		resHeader := t.simpleSchema(t.resolve(asMap(header["schema"])))
+		if description, ok := header["description"]; ok {
+			resHeader["description"] = description
+		}
+
+		if resHeader["type"] == "array" {
+			resHeader["collectionFormat"] = "csv"
+		}
+
+		if header["required"] == true {
+			resHeader[requiredExtension] = true
+		}
+
+		res[name] = resHeader
+	}
+
+	return res
+}
+
+// resolve follow the references to the components until a concrete object is
+// found.
+func (t *oas3Converter) resolve(obj map[string]interface{}) map[string]interface{} {
+	for i := 0; i < maxRefHops; i++ {
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return obj
+		}
+
+		// The schemas already converted point to the definitions.
+		if strings.HasPrefix(ref, "#/definitions/") {
+			ref = "#/components/schemas/" + strings.TrimPrefix(ref, "#/definitions/")
+		}
+
+		if !strings.HasPrefix(ref, "#/components/") {
+			return obj
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(ref, "#/components/"), "/", 2)
+		if len(parts) != 2 {
+			return obj
+		}
+
+		target, ok := asMap(t.components[parts[0]])[unescapePointerToken(parts[1])].(map[string]interface{})
+		if !ok {
+			return obj
+		}
+
+		obj = target
+	}
+
+	return obj
+}
+
+// convertContent convert the schemas of a content map, indexed by media
+// type.
+func (t *oas3Converter) convertContent(content map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+
+	for mediaType, media := range content {
+		if schema, ok := asMap(media)["schema"]; ok {
+			res[mediaType] = t.convertSchema(asMap(schema))
+		}
+	}
+
+	return res
+}
+
+// convertSchema translate in place an OpenAPI 3 schema into a Swagger 2.0 one.
+//
+// The references to the components schemas are redirected to the definitions
+// and the OpenAPI 3 only keywords are replaced by their vendor extensions
+// equivalents. Converting an already converted schema is a no-op.
+func (t *oas3Converter) convertSchema(schema map[string]interface{}) map[string]interface{} {
+	if schema == nil {
+		return map[string]interface{}{}
+	}
+
+	if t.jsonSchema2020 {
+		walkSchema(schema, t.downgradeSchema)
+	}
+
+	walkSchema(schema, func(s map[string]interface{}) {
+		if ref, ok := s["$ref"].(string); ok && strings.HasPrefix(ref, "#/components/schemas/") {
+			s["$ref"] = "#/definitions/" + strings.TrimPrefix(ref, "#/components/schemas/")
+		}
+
+		if nullable, ok := s["nullable"].(bool); ok {
+			delete(s, "nullable")
+			if nullable {
+				s["x-nullable"] = true
+			}
+		}
+
+		if discriminator, ok := s["discriminator"].(map[string]interface{}); ok {
+			s["discriminator"] = discriminator["propertyName"]
+			if mapping := discriminatorMapping(discriminator, s); len(mapping) > 0 {
+				s[discriminatorMappingExtension] = mapping
+			}
+		}
+	})
+
+	return schema
+}
+
+// walkSchema call fn on the given raw schema and then on each of its
+// sub-schemas.
+func walkSchema(schema map[string]interface{}, fn func(map[string]interface{})) {
+	fn(schema)
+
+	for _, key := range subSchemaKeywords {
+		walkSchemaValue(schema[key], fn)
+	}
+
+	for _, key := range subSchemaMapKeywords {
+		for _, sub := range asMap(schema[key]) {
+			walkSchemaValue(sub, fn)
+		}
+	}
+}
+
+func walkSchemaValue(value interface{}, fn func(map[string]interface{})) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		walkSchema(value, fn)
+	case []interface{}:
+		for _, item := range value {
+			walkSchemaValue(item, fn)
+		}
+	}
+}
+
+func primaryMediaType(mediaTypes []string) string {
+	for _, mediaType := range mediaTypes {
+		if isJSONMediaType(mediaType) {
+			return mediaType
+		}
+	}
+
+	for _, mediaType := range mediaTypes {
+		if isFormMediaType(mediaType) {
+			return mediaType
+		}
+	}
+
+	if len(mediaTypes) > 0 {
+		return mediaTypes[0]
+	}
+
+	return ""
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return strings.HasSuffix(mediaType, "/json") || strings.HasSuffix(mediaType, "+json")
+}
+
+func isFormMediaType(mediaType string) bool {
+	return mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data"
+}
+
+func unescapePointerToken(token string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+}
+
+func asMap(value interface{}) map[string]interface{} {
+	res, _ := value.(map[string]interface{})
+
+	return res
+}
+
+func asSlice(value interface{}) []interface{} {
+	res, _ := value.([]interface{})
+
+	return res
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	res := make([]string, 0, len(m))
+	for key := range m {
+		res = append(res, key)
+	}
+
+	sort.Strings(res)
+
+	return res
+}
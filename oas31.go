@@ -0,0 +1,233 @@
+package oaichecker
+
+import (
+	"strconv"
+	"strings"
+)
+
+// hoistDefs move the "$defs" declared inside the components schemas, at any
+// depth, next to them, and redirect the references accordingly.
+//
+// A definition keeps its name unless another component schema already uses
+// it, in which case it is prefixed by the name of its owner. The local
+// references ("#/$defs/...") are resolved against their owner.
+func (t *oas3Converter) hoistDefs(doc map[string]interface{}) {
+	schemas := asMap(t.components["schemas"])
+	if schemas == nil {
+		return
+	}
+
+	renames := map[string]string{}
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+
+	for _, owner := range sortedKeys(schemas) {
+		var defs []hoistedDef
+		collectDefs(schemas[owner], "", &defs)
+
+		if len(defs) == 0 {
+			continue
+		}
+
+		local := map[string]string{}
+		for _, def := range defs {
+			newName := def.name
+			if _, exists := schemas[newName]; exists {
+				newName = owner + "." + def.name
+			}
+
+			schemas[newName] = def.schema
+
+			local["#"+def.pointer] = newName
+			renames["#/components/schemas/"+escaper.Replace(owner)+def.pointer] = newName
+		}
+
+		rewriteRefs(schemas[owner], local)
+		for _, def := range defs {
+			rewriteRefs(def.schema, local)
+		}
+	}
+
+	rewriteRefs(doc, renames)
+}
+
+// hoistedDef is a definition found inside the "$defs" of a schema, with its
+// JSON pointer relatively to its owner.
+type hoistedDef struct {
+	name    string
+	pointer string
+	schema  interface{}
+}
+
+// collectDefs remove the "$defs" found inside value, nested ones included,
+// and append their definitions to defs. path is the JSON pointer of value
+// relatively to its owner.
+func collectDefs(value interface{}, path string, defs *[]hoistedDef) {
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(value) {
+			if key != "$defs" {
+				collectDefs(value[key], path+"/"+escaper.Replace(key), defs)
+				continue
+			}
+
+			declared := asMap(value[key])
+			for _, name := range sortedKeys(declared) {
+				pointer := path + "/$defs/" + escaper.Replace(name)
+
+				*defs = append(*defs, hoistedDef{name: name, pointer: pointer, schema: declared[name]})
+				collectDefs(declared[name], pointer, defs)
+			}
+
+			delete(value, key)
+		}
+	case []interface{}:
+		for i, item := range value {
+			collectDefs(item, path+"/"+strconv.Itoa(i), defs)
+		}
+	}
+}
+
+// rewriteRefs replace, inside the whole given value, the references found in
+// renames by the matching component schema.
+func rewriteRefs(value interface{}, renames map[string]string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := value["$ref"].(string); ok {
+			if newName, ok := renames[ref]; ok {
+				value["$ref"] = "#/components/schemas/" + newName
+			}
+		}
+
+		for _, sub := range value {
+			rewriteRefs(sub, renames)
+		}
+	case []interface{}:
+		for _, item := range value {
+			rewriteRefs(item, renames)
+		}
+	}
+}
+
+// downgradeSchema rewrite in place the JSON Schema 2020-12 keywords of a
+// schema into their draft 4 equivalents, the only draft handled by
+// go-openapi/validate.
+//
+// The "type" arrays are kept as is as they are already valid in draft 4.
+func (t *oas3Converter) downgradeSchema(s map[string]interface{}) {
+	// Since 2019-09, the keywords next to a "$ref" are not ignored anymore.
+	// The "unevaluatedProperties" stays on the composed schema in order to
+	// see the properties of the referenced one.
+	if ref, ok := s["$ref"]; ok && len(s) > 1 {
+		siblings := map[string]interface{}{}
+		for key, value := range s {
+			if key != "$ref" && key != "unevaluatedProperties" {
+				siblings[key] = value
+				delete(s, key)
+			}
+		}
+
+		delete(s, "$ref")
+		s["allOf"] = []interface{}{
+			map[string]interface{}{"$ref": ref},
+			siblings,
+		}
+	}
+
+	if value, ok := s["const"]; ok {
+		delete(s, "const")
+
+		if _, ok := s["enum"]; ok {
+			s["allOf"] = append(asSlice(s["allOf"]), map[string]interface{}{
+				"enum": []interface{}{value},
+			})
+		} else {
+			s["enum"] = []interface{}{value}
+		}
+	}
+
+	for _, keyword := range []string{"Minimum", "Maximum"} {
+		limitKey := strings.ToLower(keyword)
+		exclusiveKey := "exclusive" + keyword
+
+		if limit, ok := s[exclusiveKey].(float64); ok {
+			s[limitKey] = limit
+			s[exclusiveKey] = true
+		}
+	}
+
+	if prefixItems, ok := s["prefixItems"]; ok {
+		delete(s, "prefixItems")
+
+		if items, ok := s["items"]; ok {
+			s["additionalItems"] = items
+		}
+
+		s["items"] = prefixItems
+	}
+
+	if unevaluated, ok := s["unevaluatedItems"]; ok {
+		delete(s, "unevaluatedItems")
+
+		if _, ok := s["items"].([]interface{}); ok {
+			s["additionalItems"] = unevaluated
+		} else if _, ok := s["items"]; !ok {
+			s["items"] = unevaluated
+		}
+	}
+
+	if unevaluated, ok := s["unevaluatedProperties"]; ok {
+		delete(s, "unevaluatedProperties")
+
+		// The draft 4 "additionalProperties" only see the properties declared
+		// beside it, so the ones evaluated by the sub-schemas are redeclared
+		// with an empty schema.
+		properties := asMap(s["properties"])
+		for _, name := range t.evaluatedProperties(s, 0) {
+			if _, ok := properties[name]; !ok {
+				if properties == nil {
+					properties = map[string]interface{}{}
+				}
+
+				properties[name] = map[string]interface{}{}
+			}
+		}
+
+		if properties != nil {
+			s["properties"] = properties
+		}
+
+		s["additionalProperties"] = unevaluated
+	}
+}
+
+// evaluatedProperties list the properties declared by the reference and the
+// composition keywords of the given schema, following the references to the
+// components.
+func (t *oas3Converter) evaluatedProperties(s map[string]interface{}, depth int) []string {
+	if depth > maxRefHops {
+		return nil
+	}
+
+	var res []string
+
+	if _, ok := s["$ref"]; ok {
+		// The unresolved references are external ones, left to go-openapi.
+		if target := t.resolve(s); target["$ref"] == nil {
+			res = append(res, sortedKeys(asMap(target["properties"]))...)
+			res = append(res, t.evaluatedProperties(target, depth+1)...)
+		}
+	}
+
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		for _, rawSub := range asSlice(s[key]) {
+			sub := t.resolve(asMap(rawSub))
+
+			res = append(res, sortedKeys(asMap(sub["properties"]))...)
+			res = append(res, t.evaluatedProperties(sub, depth+1)...)
+		}
+	}
+
+	return res
+}
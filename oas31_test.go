@@ -0,0 +1,207 @@
+package oaichecker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_oas3Converter_downgradeSchema_with_ref_siblings(t *testing.T) {
+	schema := map[string]interface{}{
+		"$ref":        "#/components/schemas/Pet",
+		"description": "some-description",
+	}
+
+	new(oas3Converter).downgradeSchema(schema)
+
+	assert.Equal(t, map[string]interface{}{
+		"allOf": []interface{}{
+			map[string]interface{}{"$ref": "#/components/schemas/Pet"},
+			map[string]interface{}{"description": "some-description"},
+		},
+	}, schema)
+}
+
+func Test_oas3Converter_downgradeSchema_with_const_and_exclusive_limits(t *testing.T) {
+	schema := map[string]interface{}{
+		"const":            float64(42),
+		"exclusiveMinimum": float64(0),
+	}
+
+	new(oas3Converter).downgradeSchema(schema)
+
+	assert.Equal(t, map[string]interface{}{
+		"enum":             []interface{}{float64(42)},
+		"minimum":          float64(0),
+		"exclusiveMinimum": true,
+	}, schema)
+}
+
+func Test_oas3Converter_downgradeSchema_with_prefixItems(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":        "array",
+		"prefixItems": []interface{}{map[string]interface{}{"type": "string"}},
+		"items":       false,
+	}
+
+	new(oas3Converter).downgradeSchema(schema)
+
+	assert.Equal(t, map[string]interface{}{
+		"type":            "array",
+		"items":           []interface{}{map[string]interface{}{"type": "string"}},
+		"additionalItems": false,
+	}, schema)
+}
+
+func Test_oas3Converter_downgradeSchema_with_unevaluatedProperties(t *testing.T) {
+	c := oas3Converter{
+		components: map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Named": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"name": map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+		},
+	}
+
+	schema := map[string]interface{}{
+		"allOf": []interface{}{
+			map[string]interface{}{"$ref": "#/components/schemas/Named"},
+		},
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{"type": "integer"},
+		},
+		"unevaluatedProperties": false,
+	}
+
+	c.downgradeSchema(schema)
+
+	assert.Equal(t, map[string]interface{}{
+		"allOf": []interface{}{
+			map[string]interface{}{"$ref": "#/components/schemas/Named"},
+		},
+		"properties": map[string]interface{}{
+			"id":   map[string]interface{}{"type": "integer"},
+			"name": map[string]interface{}{},
+		},
+		"additionalProperties": false,
+	}, schema)
+}
+
+func Test_oas3Converter_downgradeSchema_with_unevaluatedProperties_next_to_ref(t *testing.T) {
+	c := oas3Converter{
+		components: map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Named": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"name": map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+		},
+	}
+
+	schema := map[string]interface{}{
+		"$ref":                  "#/components/schemas/Named",
+		"unevaluatedProperties": false,
+	}
+
+	c.downgradeSchema(schema)
+
+	assert.Equal(t, map[string]interface{}{
+		"allOf": []interface{}{
+			map[string]interface{}{"$ref": "#/components/schemas/Named"},
+			map[string]interface{}{},
+		},
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{},
+		},
+		"additionalProperties": false,
+	}, schema)
+}
+
+func Test_oas3Converter_hoistDefs(t *testing.T) {
+	doc := map[string]interface{}{
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Pet": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"location": map[string]interface{}{"$ref": "#/$defs/Point"},
+					},
+					"$defs": map[string]interface{}{
+						"Point": map[string]interface{}{"type": "array"},
+					},
+				},
+			},
+		},
+	}
+
+	c := oas3Converter{components: asMap(doc["components"])}
+	c.hoistDefs(doc)
+
+	assert.Equal(t, map[string]interface{}{
+		"Pet": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"location": map[string]interface{}{"$ref": "#/components/schemas/Point"},
+			},
+		},
+		"Point": map[string]interface{}{"type": "array"},
+	}, c.components["schemas"])
+}
+
+func Test_oas3Converter_hoistDefs_with_same_names(t *testing.T) {
+	doc := map[string]interface{}{
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Pet": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"location": map[string]interface{}{"$ref": "#/$defs/Point"},
+					},
+					"$defs": map[string]interface{}{
+						"Point": map[string]interface{}{"type": "array"},
+					},
+				},
+				"Store": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"location": map[string]interface{}{"$ref": "#/$defs/Point"},
+						"area":     map[string]interface{}{"$ref": "#/$defs/Area"},
+					},
+					"$defs": map[string]interface{}{
+						"Point": map[string]interface{}{"type": "string"},
+						"Area": map[string]interface{}{
+							"items": map[string]interface{}{"$ref": "#/$defs/Area/$defs/Corner"},
+							"$defs": map[string]interface{}{
+								"Corner": map[string]interface{}{"type": "integer"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	c := oas3Converter{components: asMap(doc["components"])}
+	c.hoistDefs(doc)
+
+	assert.Equal(t, map[string]interface{}{
+		"Pet": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"location": map[string]interface{}{"$ref": "#/components/schemas/Point"},
+			},
+		},
+		"Point": map[string]interface{}{"type": "array"},
+		"Store": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"location": map[string]interface{}{"$ref": "#/components/schemas/Store.Point"},
+				"area":     map[string]interface{}{"$ref": "#/components/schemas/Area"},
+			},
+		},
+		"Store.Point": map[string]interface{}{"type": "string"},
+		"Area": map[string]interface{}{
+			"items": map[string]interface{}{"$ref": "#/components/schemas/Corner"},
+		},
+		"Corner": map[string]interface{}{"type": "integer"},
+	}, c.components["schemas"])
+}
@@ -0,0 +1,154 @@
+package oaichecker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isOpenAPI3(t *testing.T) {
+	assert.True(t, isOpenAPI3(map[string]interface{}{"openapi": "3.0.2"}))
+	assert.False(t, isOpenAPI3(map[string]interface{}{"swagger": "2.0"}))
+	assert.False(t, isOpenAPI3(map[string]interface{}{"openapi": 3}))
+}
+
+func Test_collectionFormat(t *testing.T) {
+	tests := []struct {
+		in       string
+		param    map[string]interface{}
+		expected string
+	}{
+		{"query", map[string]interface{}{}, "multi"},
+		{"query", map[string]interface{}{"explode": false}, "csv"},
+		{"query", map[string]interface{}{"style": "spaceDelimited"}, "ssv"},
+		{"query", map[string]interface{}{"style": "pipeDelimited"}, "pipes"},
+		{"query", map[string]interface{}{"style": "spaceDelimited", "explode": true}, "multi"},
+		{"query", map[string]interface{}{"style": "pipeDelimited", "explode": true}, "multi"},
+		{"path", map[string]interface{}{"style": "label", "explode": true}, "csv"},
+		{"path", map[string]interface{}{"style": "matrix"}, "csv"},
+		{"path", map[string]interface{}{}, "csv"},
+		{"header", map[string]interface{}{"style": "simple", "explode": true}, "csv"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, collectionFormat(test.in, test.param), "%s %v", test.in, test.param)
+	}
+}
+
+func Test_convertSchema(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":     "object",
+		"nullable": true,
+		"discriminator": map[string]interface{}{
+			"propertyName": "petType",
+		},
+		"properties": map[string]interface{}{
+			"nullable": map[string]interface{}{
+				"$ref": "#/components/schemas/Tag",
+			},
+		},
+	}
+
+	res := new(oas3Converter).convertSchema(schema)
+
+	assert.Equal(t, map[string]interface{}{
+		"type":          "object",
+		"x-nullable":    true,
+		"discriminator": "petType",
+		"properties": map[string]interface{}{
+			"nullable": map[string]interface{}{
+				"$ref": "#/definitions/Tag",
+			},
+		},
+	}, res)
+}
+
+func Test_convertOpenAPI3_with_servers(t *testing.T) {
+	res := convertOpenAPI3(map[string]interface{}{
+		"openapi": "3.0.0",
+		"servers": []interface{}{
+			map[string]interface{}{
+				"url": "{scheme}://petstore.swagger.io/v1",
+				"variables": map[string]interface{}{
+					"scheme": map[string]interface{}{"default": "https"},
+				},
+			},
+		},
+	})
+
+	assert.Equal(t, "petstore.swagger.io", res["host"])
+	assert.Equal(t, "/v1", res["basePath"])
+	assert.Equal(t, []interface{}{"https"}, res["schemes"])
+}
+
+func Test_convertHeaders(t *testing.T) {
+	res := new(oas3Converter).convertHeaders(map[string]interface{}{
+		"X-Rate-Limit": map[string]interface{}{
+			"required": true,
+			"schema":   map[string]interface{}{"type": "integer"},
+		},
+		"X-Tags": map[string]interface{}{
+			"schema": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+			},
+		},
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"type":                  "integer",
+		"x-oaichecker-required": true,
+	}, res["X-Rate-Limit"])
+	assert.Equal(t, "csv", asMap(res["X-Tags"])["collectionFormat"])
+	assert.NotContains(t, asMap(res["X-Tags"]), "x-oaichecker-required")
+}
+
+func Test_convertResponses_with_ranges(t *testing.T) {
+	res, _ := new(oas3Converter).convertResponses(map[string]interface{}{
+		"200": map[string]interface{}{"description": "ok"},
+		"4xx": map[string]interface{}{"description": "client error"},
+		"2XX": map[string]interface{}{"description": "success"},
+		"4X2": map[string]interface{}{"description": "invalid"},
+	})
+
+	assert.Contains(t, res, "200")
+	assert.NotContains(t, res, "4xx")
+	assert.NotContains(t, res, "4X2")
+	assert.Equal(t, map[string]interface{}{
+		"2XX": map[string]interface{}{"description": "success"},
+		"4XX": map[string]interface{}{"description": "client error"},
+	}, res[rangesExtension])
+}
+
+func Test_convertSecurityScheme(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"type": "apiKey",
+		"in":   "query",
+		"name": "key",
+	}, convertSecurityScheme(map[string]interface{}{"type": "apiKey", "in": "query", "name": "key"}))
+
+	assert.Equal(t, map[string]interface{}{
+		"type": "basic",
+	}, convertSecurityScheme(map[string]interface{}{"type": "http", "scheme": "basic"}))
+
+	assert.Equal(t, map[string]interface{}{
+		"type": "apiKey",
+		"in":   "header",
+		"name": "Authorization",
+	}, convertSecurityScheme(map[string]interface{}{"type": "http", "scheme": "bearer"}))
+
+	assert.Equal(t, map[string]interface{}{
+		"type":     "oauth2",
+		"flow":     "application",
+		"tokenUrl": "https://example.com/token",
+		"scopes":   map[string]interface{}{"read": "read access"},
+	}, convertSecurityScheme(map[string]interface{}{
+		"type": "oauth2",
+		"flows": map[string]interface{}{
+			"clientCredentials": map[string]interface{}{
+				"tokenUrl": "https://example.com/token",
+				"scopes":   map[string]interface{}{"read": "read access"},
+			},
+		},
+	}))
+}
@@ -0,0 +1,177 @@
+package oaichecker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/spec"
+)
+
+// collectionSeparators are the separators of the array parameters values,
+// indexed by collectionFormat. The "multi" format uses several values
+// instead.
+var collectionSeparators = map[string]string{
+	"":      ",",
+	"csv":   ",",
+	"ssv":   " ",
+	"tsv":   "\t",
+	"pipes": "|",
+}
+
+// coerceValues convert the raw values of a parameter or a header into its
+// declared type. The arrays are split according to their collectionFormat,
+// then each item is converted.
+func coerceValues(name string, in string, schema *spec.SimpleSchema, values []string) (interface{}, error) {
+	if schema.Type != "array" {
+		res, ok := coerceValue(values[0], schema.Type, schema.Format)
+		if !ok {
+			return nil, coercionViolation(name, in, schema.Type, "", values[0])
+		}
+
+		return res, nil
+	}
+
+	items := splitCollection(values, schema.CollectionFormat)
+	if schema.Items == nil {
+		return items, nil
+	}
+
+	res := make([]interface{}, 0, len(items))
+	var errs []error
+
+	for i, item := range items {
+		value, ok := coerceValue(item, schema.Items.Type, schema.Items.Format)
+		if !ok {
+			index := strconv.Itoa(i)
+			errs = append(errs, coercionViolation(name+"."+index, in, schema.Items.Type, "/"+index, item))
+			continue
+		}
+
+		res = append(res, value)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.CompositeValidationError(errs...)
+	}
+
+	return res, nil
+}
+
+func coercionViolation(name string, in string, typ string, pointer string, value string) violation {
+	return violation{
+		keyword: "type",
+		message: fmt.Sprintf("%s in %s is not a valid %s: %q", name, in, typ, value),
+		pointer: pointer,
+		value:   value,
+	}
+}
+
+// splitCollection split the raw values of an array parameter according to
+// its collectionFormat.
+//
+// Several values are accepted for all the formats: they are concatenated, as
+// for the repeated headers.
+func splitCollection(values []string, collectionFormat string) []string {
+	separator, ok := collectionSeparators[collectionFormat]
+	if !ok {
+		return values
+	}
+
+	var res []string
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+
+		res = append(res, strings.Split(value, separator)...)
+	}
+
+	return res
+}
+
+// unstyleValue remove the prefix of a path parameter value serialized with
+// the OpenAPI 3 "label" (".5") or "matrix" (";id=5") style, splitting the
+// exploded arrays. The boolean is false if the prefix is missing.
+func unstyleValue(name string, style string, explode bool, value string) ([]string, bool) {
+	separator, prefix := ".", "."
+	if style == "matrix" {
+		separator, prefix = ";", ";"+name+"="
+	}
+
+	if !strings.HasPrefix(value, prefix) {
+		return nil, false
+	}
+
+	if !explode {
+		return []string{strings.TrimPrefix(value, prefix)}, true
+	}
+
+	var res []string
+	for _, item := range strings.Split(value, separator)[1:] {
+		if style == "matrix" {
+			if !strings.HasPrefix(item, name+"=") {
+				return nil, false
+			}
+
+			item = strings.TrimPrefix(item, name+"=")
+		}
+
+		res = append(res, item)
+	}
+
+	return res, true
+}
+
+// coerceValue convert a raw value into the given type and format, the
+// boolean being false if the value is invalid.
+func coerceValue(value string, typ string, format string) (interface{}, bool) {
+	switch typ {
+	case "integer":
+		bitSize := 64
+		if format == "int32" {
+			bitSize = 32
+		}
+
+		res, err := strconv.ParseInt(value, 10, bitSize)
+		if err != nil {
+			return nil, false
+		}
+
+		return res, true
+	case "number":
+		bitSize := 64
+		if format == "float" {
+			bitSize = 32
+		}
+
+		res, err := strconv.ParseFloat(value, bitSize)
+		if err != nil {
+			return nil, false
+		}
+
+		return res, true
+	case "boolean":
+		res, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, false
+		}
+
+		return res, true
+	default:
+		return value, true
+	}
+}
+
+// parseSimpleValue convert a raw value into the given type. The value is
+// kept as is if it can't be converted in order to let the validators report
+// the type violation.
+func parseSimpleValue(value string, typ string) interface{} {
+	res, ok := coerceValue(value, typ, "")
+	if !ok {
+		return value
+	}
+
+	return res
+}
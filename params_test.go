@@ -0,0 +1,245 @@
+package oaichecker
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_splitCollection(t *testing.T) {
+	tests := []struct {
+		collectionFormat string
+		values           []string
+		expected         []string
+	}{
+		{"", []string{"a,b"}, []string{"a", "b"}},
+		{"csv", []string{"a,b", "c"}, []string{"a", "b", "c"}},
+		{"ssv", []string{"a b"}, []string{"a", "b"}},
+		{"tsv", []string{"a\tb"}, []string{"a", "b"}},
+		{"pipes", []string{"a|b"}, []string{"a", "b"}},
+		{"multi", []string{"a,b", "c"}, []string{"a,b", "c"}},
+		{"csv", []string{""}, nil},
+		{"csv", nil, nil},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, splitCollection(test.values, test.collectionFormat), test.collectionFormat)
+	}
+}
+
+func Test_coerceValues(t *testing.T) {
+	param := spec.QueryParam("limit").Typed("integer", "int32")
+
+	res, err := coerceValues(param.Name, param.In, &param.SimpleSchema, []string{"10", "20"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), res)
+
+	_, err = coerceValues(param.Name, param.In, &param.SimpleSchema, []string{"4294967296"})
+	assert.EqualError(t, err, "limit in query is not a valid integer: \"4294967296\"")
+
+	param = spec.QueryParam("ratios").CollectionOf(spec.NewItems().Typed("number", ""), "pipes")
+
+	res, err = coerceValues(param.Name, param.In, &param.SimpleSchema, []string{"0.5|1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{0.5, float64(1)}, res)
+
+	_, err = coerceValues(param.Name, param.In, &param.SimpleSchema, []string{"0.5|half"})
+	assert.EqualError(t, err, "validation failure list:\n"+
+		"ratios.1 in query is not a valid number: \"half\"")
+}
+
+func Test_unstyleValue(t *testing.T) {
+	tests := []struct {
+		style    string
+		explode  bool
+		value    string
+		expected []string
+	}{
+		{"label", false, ".5", []string{"5"}},
+		{"label", false, ".3,4", []string{"3,4"}},
+		{"label", true, ".3.4", []string{"3", "4"}},
+		{"matrix", false, ";id=5", []string{"5"}},
+		{"matrix", false, ";id=3,4", []string{"3,4"}},
+		{"matrix", true, ";id=3;id=4", []string{"3", "4"}},
+		{"label", false, "5", nil},
+		{"matrix", true, ";id=3;other=4", nil},
+	}
+
+	for _, test := range tests {
+		res, ok := unstyleValue("id", test.style, test.explode, test.value)
+
+		assert.Equal(t, test.expected != nil, ok, test.value)
+		assert.Equal(t, test.expected, res, test.value)
+	}
+}
+
+func Test_Analyzer_AnalyzeRequest_with_styled_path_parameters(t *testing.T) {
+	specs, err := NewSpecsFromRaw([]byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "pets", "version": "1.0.0"},
+		"paths": {
+			"/pets/{id}/{tags}": {
+				"get": {
+					"parameters": [
+						{"name": "id", "in": "path", "required": true, "style": "label", "schema": {"type": "integer"}},
+						{
+							"name": "tags",
+							"in": "path",
+							"required": true,
+							"style": "matrix",
+							"explode": true,
+							"schema": {"type": "array", "items": {"type": "integer"}}
+						}
+					],
+					"responses": {"200": {"description": "ok"}}
+				}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+	require.NoError(t, specs.Validate())
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/pets/.5/;tags=3;tags=4", nil)
+	require.NoError(t, err)
+
+	assert.NoError(t, analyzer.AnalyzeRequest(req))
+
+	req, err = http.NewRequest("GET", "/pets/5/;tags=3;tags=a", nil)
+	require.NoError(t, err)
+
+	err = analyzer.AnalyzeRequest(req)
+
+	assert.EqualError(t, err, "validation failure list:\n"+
+		`id in path is not a valid label style value: "5"`+"\n"+
+		`tags.1 in path is not a valid integer: "a"`)
+}
+
+func Test_coerceValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		typ      string
+		format   string
+		expected interface{}
+		ok       bool
+	}{
+		{"42", "integer", "", int64(42), true},
+		{"9223372036854775808", "integer", "int64", nil, false},
+		{"4.2", "integer", "", nil, false},
+		{"4.2", "number", "", 4.2, true},
+		{"true", "boolean", "", true, true},
+		{"yes", "boolean", "", nil, false},
+		{"yes", "string", "", "yes", true},
+	}
+
+	for _, test := range tests {
+		res, ok := coerceValue(test.value, test.typ, test.format)
+
+		assert.Equal(t, test.ok, ok, test.value)
+		assert.Equal(t, test.expected, res, test.value)
+	}
+}
+
+func Test_Analyzer_AnalyzeRequest_with_collection_formats(t *testing.T) {
+	specs, err := NewSpecsFromRaw([]byte(`{
+		"swagger": "2.0",
+		"info": {"title": "collections", "version": "1.0.0"},
+		"paths": {
+			"/pets": {
+				"get": {
+					"parameters": [
+						{
+							"name": "status",
+							"in": "query",
+							"type": "array",
+							"items": {"type": "string", "enum": ["available", "sold"]},
+							"collectionFormat": "csv"
+						},
+						{
+							"name": "X-Tags",
+							"in": "header",
+							"type": "array",
+							"items": {"type": "string"},
+							"collectionFormat": "pipes",
+							"maxItems": 2
+						}
+					],
+					"responses": {"200": {"description": "ok"}}
+				}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/pets?status=available,sold", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Tags", "a|b")
+
+	err = analyzer.AnalyzeRequest(req)
+	assert.NoError(t, err)
+
+	req, err = http.NewRequest("GET", "/pets?status=available,unknown", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Tags", "a|b|c")
+
+	err = analyzer.AnalyzeRequest(req)
+	assert.EqualError(t, err, "validation failure list:\n"+
+		"status.1 in query should be one of [available sold]\n"+
+		"X-Tags in header should have at most 2 items")
+}
+
+func Test_Analyzer_AnalyzeRequest_with_invalid_coerced_values(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/v2/pet/9223372036854775808", nil)
+	require.NoError(t, err)
+	req.Header.Set("userID", "42")
+
+	err = analyzer.AnalyzeRequest(req)
+
+	require.IsType(t, ValidationErrors{}, err)
+	validationErr := err.(ValidationErrors)[0]
+
+	assert.Equal(t, "type", validationErr.Keyword)
+	assert.Equal(t, "9223372036854775808", validationErr.Value)
+	assert.EqualError(t, err, "validation failure list:\n"+
+		"petId in path is not a valid integer: \"9223372036854775808\"")
+}
+
+func Test_Analyzer_AnalyzeRequest_with_allowed_empty_value(t *testing.T) {
+	specs, err := NewSpecsFromRaw([]byte(`{
+		"swagger": "2.0",
+		"info": {"title": "pets", "version": "1.0.0"},
+		"paths": {
+			"/pets": {
+				"get": {
+					"parameters": [
+						{"name": "limit", "in": "query", "type": "integer", "allowEmptyValue": true},
+						{"name": "offset", "in": "query", "type": "integer"}
+					],
+					"responses": {"200": {"description": "ok"}}
+				}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/pets?limit=&offset=", nil)
+	require.NoError(t, err)
+
+	err = analyzer.AnalyzeRequest(req)
+
+	assert.EqualError(t, err, "validation failure list:\n"+
+		`offset in query is not a valid integer: ""`)
+}
@@ -0,0 +1,359 @@
+package oaichecker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/go-openapi/jsonpointer"
+)
+
+// documentLoader load the raw content of the document found at the given
+// location.
+type documentLoader func(location string) ([]byte, error)
+
+// refResolver resolve the references to external documents.
+//
+// The external schemas are imported into the definitions of the main document
+// and referenced locally, which keeps the recursive schemas as is. The other
+// external references are inlined. The relative locations are resolved
+// against the location of the document containing the reference, as for the
+// URLs.
+type refResolver struct {
+	load      documentLoader
+	location  string
+	documents map[string]interface{}
+
+	// definitionsPath is the local reference prefix of the main document
+	// definitions, depending on its version.
+	definitionsPath string
+	// names map the external schemas, by location and fragment, to the name of
+	// their definition.
+	names       map[string]string
+	definitions map[string]interface{}
+}
+
+// schemaKeywords are the keywords having a schema, or a list of schemas, as
+// value.
+var schemaKeywords = map[string]bool{
+	"schema":               true,
+	"items":                true,
+	"additionalItems":      true,
+	"additionalProperties": true,
+	"not":                  true,
+	"allOf":                true,
+	"anyOf":                true,
+	"oneOf":                true,
+	"prefixItems":          true,
+}
+
+// schemaMapKeywords are the keywords having a map of schemas as value.
+var schemaMapKeywords = map[string]bool{
+	"definitions":       true,
+	"schemas":           true,
+	"properties":        true,
+	"patternProperties": true,
+	"$defs":             true,
+	"dependentSchemas":  true,
+}
+
+// inlineExternalRefs resolve all the references to an external document,
+// loaded with load. The external schemas are moved into the definitions of
+// the main document and the other targets are inlined. The local references
+// of the main document are kept as is.
+func inlineExternalRefs(rawSpec []byte, location string, load documentLoader) ([]byte, error) {
+	doc, err := readDocument(rawSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := refResolver{
+		load:            load,
+		location:        location,
+		documents:       map[string]interface{}{location: doc},
+		definitionsPath: "#/definitions/",
+		names:           map[string]string{},
+		definitions:     map[string]interface{}{},
+	}
+
+	definitionsKeys := []string{"definitions"}
+	if doc["openapi"] != nil {
+		resolver.definitionsPath = "#/components/schemas/"
+		definitionsKeys = []string{"components", "schemas"}
+	}
+
+	// The existing definitions names are reserved.
+	if existing, ok := nestedMap(doc, definitionsKeys); ok {
+		for name := range existing {
+			resolver.definitions[name] = existing[name]
+		}
+	}
+
+	res, err := resolver.inline(doc, location, false, false, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resolver.names) > 0 {
+		definitions := ensureNestedMap(res.(map[string]interface{}), definitionsKeys)
+		for _, name := range resolver.names {
+			definitions[name] = resolver.definitions[name]
+		}
+	}
+
+	return json.Marshal(res)
+}
+
+// inline return a copy of value with its external references resolved, value
+// being a schema if isSchema is set.
+//
+// The local references are resolved only for the external documents as their
+// target doesn't exist in the main document.
+func (t *refResolver) inline(value interface{}, location string, external bool, isSchema bool, depth int) (interface{}, error) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := value["$ref"].(string); ok && (external || !strings.HasPrefix(ref, "#")) {
+			if isSchema {
+				return t.importRef(ref, location)
+			}
+
+			return t.inlineRef(ref, location, depth)
+		}
+
+		res := make(map[string]interface{}, len(value))
+		for key, sub := range value {
+			var err error
+
+			switch {
+			case schemaMapKeywords[key]:
+				res[key], err = t.inlineSchemas(sub, location, external, depth)
+			default:
+				res[key], err = t.inline(sub, location, external, schemaKeywords[key], depth)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, 0, len(value))
+		for _, sub := range value {
+			inlined, err := t.inline(sub, location, external, isSchema, depth)
+			if err != nil {
+				return nil, err
+			}
+
+			res = append(res, inlined)
+		}
+
+		return res, nil
+	default:
+		return value, nil
+	}
+}
+
+// inlineSchemas return a copy of a map of schemas with their external
+// references resolved.
+func (t *refResolver) inlineSchemas(value interface{}, location string, external bool, depth int) (interface{}, error) {
+	schemas, ok := value.(map[string]interface{})
+	if !ok {
+		return t.inline(value, location, external, false, depth)
+	}
+
+	res := make(map[string]interface{}, len(schemas))
+	for name, schema := range schemas {
+		inlined, err := t.inline(schema, location, external, true, depth)
+		if err != nil {
+			return nil, err
+		}
+
+		res[name] = inlined
+	}
+
+	return res, nil
+}
+
+// importRef move the schema targeted by ref into the definitions of the main
+// document, only once, and return a local reference to it.
+func (t *refResolver) importRef(ref string, location string) (interface{}, error) {
+	docLocation, fragment, target, err := t.resolveRef(ref, location)
+	if err != nil {
+		return nil, err
+	}
+
+	// The schemas of the main document are already in place.
+	if docLocation == t.location {
+		return map[string]interface{}{"$ref": "#" + fragment}, nil
+	}
+
+	key := docLocation + "#" + fragment
+	name, ok := t.names[key]
+	if !ok {
+		name = t.definitionName(docLocation, fragment)
+
+		// The name is registered before resolving the target to stop on the
+		// recursive schemas.
+		t.names[key] = name
+		t.definitions[name] = nil
+
+		schema, err := t.inline(target, docLocation, true, true, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		t.definitions[name] = schema
+	}
+
+	return map[string]interface{}{"$ref": t.definitionsPath + jsonpointer.Escape(name)}, nil
+}
+
+func (t *refResolver) inlineRef(ref string, location string, depth int) (interface{}, error) {
+	if depth >= maxRefHops {
+		return nil, fmt.Errorf("too many nested references at %q", ref)
+	}
+
+	docLocation, _, target, err := t.resolveRef(ref, location)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.inline(target, docLocation, true, false, depth+1)
+}
+
+// resolveRef return the location of the document targeted by ref, the
+// fragment and the target itself.
+func (t *refResolver) resolveRef(ref string, location string) (string, string, interface{}, error) {
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	fragment := refURL.Fragment
+	refURL.Fragment = ""
+
+	docLocation, err := resolveLocation(location, refURL)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	doc, err := t.document(docLocation)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	pointer, err := jsonpointer.New(fragment)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	target, _, err := pointer.Get(doc)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("invalid reference %q: %s", ref, err)
+	}
+
+	return docLocation, fragment, target, nil
+}
+
+// definitionName return an unused definition name for the schema at fragment
+// in the document at docLocation, from the last token of the fragment or the
+// document name.
+func (t *refResolver) definitionName(docLocation string, fragment string) string {
+	base := path.Base(docLocation)
+	base = strings.TrimSuffix(base, path.Ext(base))
+
+	if pointer, err := jsonpointer.New(fragment); err == nil {
+		if tokens := pointer.DecodedTokens(); len(tokens) > 0 {
+			base = tokens[len(tokens)-1]
+		}
+	}
+
+	name := base
+	for i := 1; ; i++ {
+		if _, ok := t.definitions[name]; !ok {
+			return name
+		}
+
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+}
+
+// nestedMap return the map found in value by following keys.
+func nestedMap(value interface{}, keys []string) (map[string]interface{}, bool) {
+	for _, key := range keys {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		value = m[key]
+	}
+
+	res, ok := value.(map[string]interface{})
+
+	return res, ok
+}
+
+// ensureNestedMap return the map found in value by following keys, creating
+// the missing ones.
+func ensureNestedMap(value map[string]interface{}, keys []string) map[string]interface{} {
+	for _, key := range keys {
+		sub, ok := value[key].(map[string]interface{})
+		if !ok {
+			sub = map[string]interface{}{}
+			value[key] = sub
+		}
+
+		value = sub
+	}
+
+	return value
+}
+
+// resolveLocation resolve the location of a referenced document against the
+// location of the referencing one, either an URL or a slash separated path.
+func resolveLocation(base string, ref *url.URL) (string, error) {
+	if ref.IsAbs() {
+		return ref.String(), nil
+	}
+
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case baseURL.IsAbs():
+		return baseURL.ResolveReference(ref).String(), nil
+	case ref.Path == "":
+		return base, nil
+	case path.IsAbs(ref.Path):
+		return ref.Path, nil
+	default:
+		return path.Join(path.Dir(base), ref.Path), nil
+	}
+}
+
+// document load and decode the document at the given location, only once.
+func (t *refResolver) document(location string) (interface{}, error) {
+	if doc, ok := t.documents[location]; ok {
+		return doc, nil
+	}
+
+	rawDoc, err := t.load(location)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := readDocument(rawDoc)
+	if err != nil {
+		return nil, err
+	}
+
+	t.documents[location] = doc
+
+	return doc, nil
+}
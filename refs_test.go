@@ -0,0 +1,144 @@
+package oaichecker
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mapLoader(documents map[string]string) documentLoader {
+	return func(location string) ([]byte, error) {
+		doc, ok := documents[location]
+		if !ok {
+			return nil, errors.New("unknown document " + location)
+		}
+
+		return []byte(doc), nil
+	}
+}
+
+func Test_inlineExternalRefs(t *testing.T) {
+	load := mapLoader(map[string]string{
+		"specs/pet.json": `{"Pet": {"properties": {"tag": {"$ref": "#/Tag"}}}, "Tag": {"type": "string"}}`,
+	})
+
+	res, err := inlineExternalRefs([]byte(`{
+		"definitions": {
+			"Pet": {"$ref": "pet.json#/Pet"},
+			"Pets": {"items": {"$ref": "#/definitions/Pet"}}
+		}
+	}`), "specs/petstore.json", load)
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(res, &doc))
+
+	assert.Equal(t, map[string]interface{}{
+		"definitions": map[string]interface{}{
+			"Pet": map[string]interface{}{"$ref": "#/definitions/Pet1"},
+			"Pet1": map[string]interface{}{
+				"properties": map[string]interface{}{
+					"tag": map[string]interface{}{"$ref": "#/definitions/Tag"},
+				},
+			},
+			"Tag": map[string]interface{}{"type": "string"},
+			"Pets": map[string]interface{}{
+				"items": map[string]interface{}{"$ref": "#/definitions/Pet"},
+			},
+		},
+	}, doc)
+}
+
+func Test_inlineExternalRefs_with_recursive_schema(t *testing.T) {
+	load := mapLoader(map[string]string{
+		"node.json": `{"Node": {"items": {"$ref": "#/Node"}}}`,
+	})
+
+	res, err := inlineExternalRefs([]byte(`{"paths": {"/nodes": {"get": {"responses": {"200": {"schema": {"$ref": "node.json#/Node"}}}}}}}`), "petstore.json", load)
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(res, &doc))
+
+	assert.Equal(t, map[string]interface{}{"$ref": "#/definitions/Node"},
+		doc["paths"].(map[string]interface{})["/nodes"].(map[string]interface{})["get"].(map[string]interface{})["responses"].(map[string]interface{})["200"].(map[string]interface{})["schema"])
+	assert.Equal(t, map[string]interface{}{
+		"Node": map[string]interface{}{
+			"items": map[string]interface{}{"$ref": "#/definitions/Node"},
+		},
+	}, doc["definitions"])
+}
+
+func Test_inlineExternalRefs_with_openapi3(t *testing.T) {
+	load := mapLoader(map[string]string{
+		"pet.json": `{"type": "object"}`,
+	})
+
+	res, err := inlineExternalRefs([]byte(`{"openapi": "3.0.0", "paths": {"/pets": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"$ref": "pet.json"}}}}}}}}}`), "petstore.json", load)
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(res, &doc))
+
+	assert.Equal(t, map[string]interface{}{
+		"schemas": map[string]interface{}{
+			"pet": map[string]interface{}{"type": "object"},
+		},
+	}, doc["components"])
+}
+
+func Test_inlineExternalRefs_with_external_parameter(t *testing.T) {
+	load := mapLoader(map[string]string{
+		"params.json": `{"Limit": {"name": "limit", "in": "query", "type": "integer"}}`,
+	})
+
+	res, err := inlineExternalRefs([]byte(`{"parameters": [{"$ref": "params.json#/Limit"}]}`), "petstore.json", load)
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(res, &doc))
+
+	assert.Equal(t, map[string]interface{}{
+		"parameters": []interface{}{
+			map[string]interface{}{"name": "limit", "in": "query", "type": "integer"},
+		},
+	}, doc)
+}
+
+func Test_inlineExternalRefs_with_invalid_pointer(t *testing.T) {
+	load := mapLoader(map[string]string{
+		"pet.json": `{}`,
+	})
+
+	_, err := inlineExternalRefs([]byte(`{"$ref": "pet.json#/Pet"}`), "petstore.json", load)
+
+	assert.Contains(t, err.Error(), `invalid reference "pet.json#/Pet"`)
+}
+
+func Test_resolveLocation(t *testing.T) {
+	tests := []struct {
+		base     string
+		ref      string
+		expected string
+	}{
+		{"specs/petstore.json", "pet.json", "specs/pet.json"},
+		{"specs/petstore.json", "../pet.json", "pet.json"},
+		{"specs/petstore.json", "", "specs/petstore.json"},
+		{"http://foo/specs/petstore.json", "pet.json", "http://foo/specs/pet.json"},
+		{"specs/petstore.json", "http://foo/pet.json", "http://foo/pet.json"},
+	}
+
+	for _, test := range tests {
+		ref, err := url.Parse(test.ref)
+		require.NoError(t, err)
+
+		location, err := resolveLocation(test.base, ref)
+
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, location)
+	}
+}
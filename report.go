@@ -0,0 +1,232 @@
+package oaichecker
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/jsonpointer"
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/validate"
+)
+
+// unusedDefinitionRegexp match the warnings about an unused definition,
+// named either by its reference or by its name depending on the go-openapi
+// version.
+var unusedDefinitionRegexp = regexp.MustCompile(`^definition (".*") is not used anywhere$`)
+
+var paramLocations = map[string]bool{
+	"path":     true,
+	"header":   true,
+	"body":     true,
+	"query":    true,
+	"formData": true,
+}
+
+// SpecsReport contains the result of the validation of some Specs.
+type SpecsReport struct {
+	Errors   []SpecsIssue
+	Warnings []SpecsIssue
+
+	errs     []error
+	warnings []error
+}
+
+// SpecsIssue is an error or a warning found inside some Specs.
+type SpecsIssue struct {
+	// Pointer is the JSON pointer to the faulty element inside the specs.
+	// It is empty when unknown.
+	Pointer string
+	Message string
+}
+
+func newSpecsReport(errs *validate.Result, warnings *validate.Result) *SpecsReport {
+	report := SpecsReport{}
+
+	if errs != nil {
+		report.errs = errs.Errors
+	}
+
+	if warnings != nil {
+		report.warnings = warnings.Errors
+	}
+
+	report.Errors = newSpecsIssues(report.errs)
+	report.Warnings = newSpecsIssues(report.warnings)
+
+	return &report
+}
+
+func newSpecsIssues(errs []error) []SpecsIssue {
+	res := make([]SpecsIssue, 0, len(errs))
+	for _, err := range errs {
+		res = append(res, SpecsIssue{
+			Pointer: issuePointer(err),
+			Message: err.Error(),
+		})
+	}
+
+	return res
+}
+
+// AsError return the errors as a single error, or nil if there is none.
+func (t *SpecsReport) AsError() error {
+	if len(t.errs) == 0 {
+		return nil
+	}
+
+	return errors.CompositeValidationError(t.errs...)
+}
+
+// AsStrictError return the errors and the warnings as a single error, or nil
+// if there is none. It allows to treat the warnings as errors.
+func (t *SpecsReport) AsStrictError() error {
+	if len(t.errs)+len(t.warnings) == 0 {
+		return nil
+	}
+
+	all := make([]error, 0, len(t.errs)+len(t.warnings))
+	all = append(all, t.errs...)
+	all = append(all, t.warnings...)
+
+	return errors.CompositeValidationError(all...)
+}
+
+// issuePointer compute the JSON pointer of the element targeted by a
+// validation error, from its dot separated location. The errors about a
+// parameter value are not located inside the specs.
+func issuePointer(err error) string {
+	if ref, ok := unusedDefinitionRef(err); ok {
+		return strings.TrimPrefix(ref, "#")
+	}
+
+	validationErr, ok := err.(*errors.Validation)
+	if !ok || paramLocations[validationErr.In] {
+		return ""
+	}
+
+	var tokens []string
+	for _, location := range []string{validationErr.In, validationErr.Name} {
+		if location != "" {
+			tokens = append(tokens, strings.Split(location, ".")...)
+		}
+	}
+
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	for i, token := range tokens {
+		tokens[i] = escaper.Replace(token)
+	}
+
+	return "/" + strings.Join(tokens, "/")
+}
+
+// unusedDefinitionRef return the reference to the definition reported as
+// unused by err, if any.
+func unusedDefinitionRef(err error) (string, bool) {
+	matches := unusedDefinitionRegexp.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return "", false
+	}
+
+	ref, unquoteErr := strconv.Unquote(matches[1])
+	if unquoteErr != nil {
+		return "", false
+	}
+
+	if !strings.HasPrefix(ref, "#/") {
+		ref = "#/definitions/" + jsonpointer.Escape(ref)
+	}
+
+	return ref, true
+}
+
+// openAPI3Warnings adapt the warnings found inside the Swagger 2.0 conversion
+// of an OpenAPI 3 specs: the definitions referenced only by the vendor
+// extensions of the conversion are used, and the other unused ones are named
+// after their schema component.
+func openAPI3Warnings(warnings []error, swagger *spec.Swagger) []error {
+	used := extensionsReferences(swagger)
+
+	res := make([]error, 0, len(warnings))
+	for _, warning := range warnings {
+		ref, ok := unusedDefinitionRef(warning)
+		if !ok {
+			res = append(res, warning)
+			continue
+		}
+
+		name := strings.TrimPrefix(ref, "#/definitions/")
+		if used[name] {
+			continue
+		}
+
+		res = append(res, errors.New(errors.CompositeErrorCode, "definition %q is not used anywhere", "#/components/schemas/"+name))
+	}
+
+	return res
+}
+
+// extensionsReferences list the escaped names of the definitions referenced
+// by the vendor extensions of the OpenAPI 3 conversion, which are ignored by
+// go-openapi: the secondary media types, the status ranges and the
+// discriminator mappings.
+func extensionsReferences(swagger *spec.Swagger) map[string]bool {
+	res := map[string]bool{}
+
+	rawSwagger, err := json.Marshal(swagger)
+	if err != nil {
+		return res
+	}
+
+	var doc interface{}
+	err = json.Unmarshal(rawSwagger, &doc)
+	if err != nil {
+		return res
+	}
+
+	collectExtensionsReferences(doc, false, res)
+
+	return res
+}
+
+func collectExtensionsReferences(value interface{}, inExtension bool, res map[string]bool) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, sub := range value {
+			switch {
+			case key == discriminatorMappingExtension:
+				for _, target := range asMap(sub) {
+					if ref, ok := target.(string); ok {
+						res[definitionToken(ref)] = true
+					}
+				}
+			case key == "$ref" && inExtension:
+				if ref, ok := sub.(string); ok {
+					res[definitionToken(ref)] = true
+				}
+			default:
+				collectExtensionsReferences(sub, inExtension || key == contentExtension || key == rangesExtension, res)
+			}
+		}
+	case []interface{}:
+		for _, sub := range value {
+			collectExtensionsReferences(sub, inExtension, res)
+		}
+	}
+}
+
+// definitionToken return the escaped name of the definition targeted by a
+// reference, or by a bare discriminator mapping name.
+func definitionToken(ref string) string {
+	if !strings.HasPrefix(ref, "#") {
+		return jsonpointer.Escape(ref)
+	}
+
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
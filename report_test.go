@@ -0,0 +1,99 @@
+package oaichecker
+
+import (
+	"testing"
+
+	"github.com/go-openapi/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Specs_Report_with_invalid_specs(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_invalid.json")
+	require.NoError(t, err)
+
+	report := specs.Report()
+
+	require.Len(t, report.Errors, 3)
+	assert.Equal(t, "items in paths./pets.get.responses.200.schema is required", report.Errors[2].Message)
+	assert.Equal(t, "/paths/~1pets/get/responses/200/schema/items", report.Errors[2].Pointer)
+}
+
+func Test_Specs_Report_with_warnings(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_unused_definition.json")
+	require.NoError(t, err)
+
+	report := specs.Report()
+
+	assert.Empty(t, report.Errors)
+	require.Len(t, report.Warnings, 1)
+	assert.Contains(t, report.Warnings[0].Message, "Tag")
+	assert.Contains(t, report.Warnings[0].Message, "is not used anywhere")
+	assert.Equal(t, "/definitions/Tag", report.Warnings[0].Pointer)
+
+	assert.NoError(t, report.AsError())
+	assert.EqualError(t, report.AsStrictError(), "validation failure list:\n"+report.Warnings[0].Message)
+}
+
+func Test_Specs_Report_with_openapi3_specs(t *testing.T) {
+	specs, err := NewSpecsFromRaw([]byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "Petstore", "version": "1.0.0"},
+		"paths": {
+			"/pets": {
+				"get": {
+					"responses": {
+						"200": {
+							"description": "The pets.",
+							"content": {
+								"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}},
+								"application/xml": {"schema": {"$ref": "#/components/schemas/XMLPet"}}
+							}
+						}
+					}
+				}
+			}
+		},
+		"components": {
+			"schemas": {
+				"Pet": {
+					"type": "object",
+					"properties": {"petType": {"type": "string"}},
+					"discriminator": {
+						"propertyName": "petType",
+						"mapping": {"dog": "#/components/schemas/Dog"}
+					}
+				},
+				"Dog": {"allOf": [{"$ref": "#/components/schemas/Pet"}]},
+				"XMLPet": {"type": "object"},
+				"Unused": {"type": "object"}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	report := specs.Report()
+
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, []SpecsIssue{{
+		Pointer: "/components/schemas/Unused",
+		Message: `definition "#/components/schemas/Unused" is not used anywhere`,
+	}}, report.Warnings)
+}
+
+func Test_Specs_Report_with_valid_specs(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_minimal.json")
+	require.NoError(t, err)
+
+	report := specs.Report()
+
+	assert.Empty(t, report.Errors)
+	assert.Empty(t, report.Warnings)
+	assert.NoError(t, report.AsStrictError())
+}
+
+func Test_issuePointer(t *testing.T) {
+	assert.Equal(t, "/paths/~1pets/get/responses/200", issuePointer(errors.InvalidType("paths./pets.get.responses.200", "", "object", "string")))
+	assert.Equal(t, "/definitions/Pet/id", issuePointer(errors.Required("id", "definitions.Pet")))
+	assert.Equal(t, "", issuePointer(errors.Required("userID", "header")))
+}
@@ -0,0 +1,429 @@
+package oaichecker
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// schemaKey identify a body schema transformed for a given phase.
+type schemaKey struct {
+	schema *spec.Schema
+	phase  Phase
+}
+
+// schemaTransform modify in place a copy of a body schema.
+type schemaTransform func(s *spec.Schema)
+
+// bodySchema return the schema used to validate a body in the given phase,
+// transformed according to the options of the Analyzer.
+//
+// The transformed schemas are computed once and cached inside the routes
+// whose specs contain the original ones, shared by all the requests.
+func (t *Analyzer) bodySchema(r *routes, schema *spec.Schema, phase Phase) *spec.Schema {
+	if schema == nil {
+		return nil
+	}
+
+	key := schemaKey{schema: schema, phase: phase}
+	if res, ok := r.schemas.Load(key); ok {
+		return res.(*spec.Schema)
+	}
+
+	res := schema
+
+	// The schemas without transformation are cached as is.
+	if transforms := t.schemaTransforms(schema, phase); len(transforms) > 0 {
+		var err error
+		res, err = copySchema(schema)
+		if err != nil {
+			return schema
+		}
+
+		for _, transform := range transforms {
+			transform(res)
+		}
+	}
+
+	r.schemas.Store(key, res)
+
+	return res
+}
+
+// schemaTransforms list the transformations to apply to the given body schema
+// in the given phase. The nullable schemas and the readOnly and writeOnly
+// properties are handled only if the schema contains some.
+func (t *Analyzer) schemaTransforms(schema *spec.Schema, phase Phase) []schemaTransform {
+	var res []schemaTransform
+
+	if containsSchema(schema, func(s *spec.Schema) bool { return isNullable(s.Extensions) }) {
+		res = append(res, allowNulls)
+	}
+
+	if containsSchema(schema, func(s *spec.Schema) bool { _, ok := isForbidden(s, phase); return ok }) {
+		res = append(res, func(s *spec.Schema) {
+			dropRequired(s, phase)
+		})
+	}
+
+	if (phase == PhaseRequest && t.options.RejectUnknownRequestProperties) ||
+		(phase == PhaseResponse && t.options.RejectUnknownResponseProperties) {
+		res = append(res, closeObjects)
+	}
+
+	return res
+}
+
+// copySchema return a deep copy of the given schema.
+func copySchema(schema *spec.Schema) (*spec.Schema, error) {
+	rawSchema, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var res spec.Schema
+	err = json.Unmarshal(rawSchema, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// eachSubSchema call fn with each direct sub-schema of s, along with the
+// keyword containing it. The modifications are stored back into s.
+func eachSubSchema(s *spec.Schema, fn func(keyword string, sub *spec.Schema)) {
+	for _, properties := range []struct {
+		keyword string
+		schemas map[string]spec.Schema
+	}{
+		{"properties", s.Properties},
+		{"patternProperties", s.PatternProperties},
+	} {
+		for name, sub := range properties.schemas {
+			fn(properties.keyword, &sub)
+			properties.schemas[name] = sub
+		}
+	}
+
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		fn("additionalProperties", s.AdditionalProperties.Schema)
+	}
+
+	if s.AdditionalItems != nil && s.AdditionalItems.Schema != nil {
+		fn("additionalItems", s.AdditionalItems.Schema)
+	}
+
+	if s.Items != nil {
+		if s.Items.Schema != nil {
+			fn("items", s.Items.Schema)
+		}
+
+		for i := range s.Items.Schemas {
+			fn("items", &s.Items.Schemas[i])
+		}
+	}
+
+	for _, composition := range []struct {
+		keyword string
+		schemas []spec.Schema
+	}{
+		{"allOf", s.AllOf},
+		{"anyOf", s.AnyOf},
+		{"oneOf", s.OneOf},
+	} {
+		for i := range composition.schemas {
+			fn(composition.keyword, &composition.schemas[i])
+		}
+	}
+
+	if s.Not != nil {
+		fn("not", s.Not)
+	}
+}
+
+// containsSchema check if s or one of its sub-schemas, recursively, matches
+// the given predicate.
+func containsSchema(s *spec.Schema, predicate func(*spec.Schema) bool) bool {
+	if predicate(s) {
+		return true
+	}
+
+	res := false
+	eachSubSchema(s, func(keyword string, sub *spec.Schema) {
+		res = res || containsSchema(sub, predicate)
+	})
+
+	return res
+}
+
+// closeObjects forbid, inside the whole schema, the properties not declared
+// by the object schemas without explicit "additionalProperties".
+//
+// The "allOf" sub-schemas are closed as a whole: their properties are
+// redeclared by the composed schema, which is closed instead of them.
+func closeObjects(s *spec.Schema) {
+	closeSchema(s, true)
+}
+
+func closeSchema(s *spec.Schema, closeSelf bool) {
+	if closeSelf && isClosable(s) {
+		for _, name := range composedProperties(s) {
+			if _, ok := s.Properties[name]; ok {
+				continue
+			}
+
+			if s.Properties == nil {
+				s.Properties = map[string]spec.Schema{}
+			}
+
+			s.Properties[name] = spec.Schema{}
+		}
+
+		s.AdditionalProperties = &spec.SchemaOrBool{Allows: false}
+	}
+
+	eachSubSchema(s, func(keyword string, sub *spec.Schema) {
+		closeSchema(sub, keyword != "allOf")
+	})
+}
+
+// isClosable check if s is an object schema accepting any additional
+// property. The schemas with a discriminator are left open, the payloads
+// being closed by their concrete schema instead.
+func isClosable(s *spec.Schema) bool {
+	if !isOpen(s) || s.Discriminator != "" {
+		return false
+	}
+
+	return s.Type.Contains("object") || len(s.Properties) > 0 || len(composedProperties(s)) > 0
+}
+
+// isOpen check if s and its "allOf" sub-schemas, recursively, have no
+// explicit constraint on the additional properties.
+func isOpen(s *spec.Schema) bool {
+	if s.AdditionalProperties != nil || len(s.PatternProperties) > 0 {
+		return false
+	}
+
+	for i := range s.AllOf {
+		if !isOpen(&s.AllOf[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// schemaProperties list the properties of a schema, including the ones declared
+// by its "allOf" sub-schemas.
+func schemaProperties(schema *spec.Schema) map[string]spec.Schema {
+	if schema == nil {
+		return nil
+	}
+
+	res := map[string]spec.Schema{}
+
+	for i := range schema.AllOf {
+		for name, property := range schemaProperties(&schema.AllOf[i]) {
+			res[name] = property
+		}
+	}
+
+	for name, property := range schema.Properties {
+		res[name] = property
+	}
+
+	return res
+}
+
+// composedProperties list the properties declared by the "allOf"
+// sub-schemas of s, recursively.
+func composedProperties(s *spec.Schema) []string {
+	var res []string
+
+	for i := range s.AllOf {
+		for name := range s.AllOf[i].Properties {
+			res = append(res, name)
+		}
+
+		res = append(res, composedProperties(&s.AllOf[i])...)
+	}
+
+	return res
+}
+
+var forbiddenDescriptions = map[string]string{
+	"readOnly":  "read only",
+	"writeOnly": "write only",
+}
+
+// isForbidden check if the given property schema is forbidden in the given
+// phase: the readOnly properties in the requests and the writeOnly ones in
+// the responses. The matching keyword is returned.
+func isForbidden(s *spec.Schema, phase Phase) (string, bool) {
+	switch {
+	case phase == PhaseRequest && s.ReadOnly:
+		return "readOnly", true
+	case phase == PhaseResponse && s.ExtraProps["writeOnly"] == true:
+		return "writeOnly", true
+	default:
+		return "", false
+	}
+}
+
+// dropRequired remove, inside the whole schema, the properties forbidden in
+// the given phase from the required ones.
+func dropRequired(s *spec.Schema, phase Phase) {
+	if len(s.Required) > 0 {
+		properties := schemaProperties(s)
+
+		required := make([]string, 0, len(s.Required))
+		for _, name := range s.Required {
+			property, ok := properties[name]
+			if _, forbidden := isForbidden(&property, phase); !ok || !forbidden {
+				required = append(required, name)
+			}
+		}
+
+		s.Required = required
+	}
+
+	eachSubSchema(s, func(keyword string, sub *spec.Schema) {
+		dropRequired(sub, phase)
+	})
+}
+
+// forbiddenProperties walk the given body value along its schema and report
+// the properties forbidden in the given phase, see isForbidden.
+func forbiddenProperties(value interface{}, s *spec.Schema, phase Phase, path string) []error {
+	if s == nil {
+		return nil
+	}
+
+	var res []error
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		properties := schemaProperties(s)
+
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			property, ok := properties[name]
+			if !ok {
+				continue
+			}
+
+			propertyPath := path + "." + name
+
+			if keyword, forbidden := isForbidden(&property, phase); forbidden {
+				res = append(res, violation{
+					keyword: keyword,
+					message: fmt.Sprintf("%s in body is %s", propertyPath, forbiddenDescriptions[keyword]),
+					pointer: payloadPointer("body", "", propertyPath),
+				})
+				continue
+			}
+
+			res = append(res, forbiddenProperties(value[name], &property, phase, propertyPath)...)
+		}
+	case []interface{}:
+		if s.Items == nil || s.Items.Schema == nil {
+			return nil
+		}
+
+		for i, item := range value {
+			res = append(res, forbiddenProperties(item, s.Items.Schema, phase, path+"."+strconv.Itoa(i))...)
+		}
+	}
+
+	return res
+}
+
+// nullableExtensions are the vendor extensions marking a schema as nullable,
+// the OpenAPI 3 "nullable" being converted into "x-nullable".
+var nullableExtensions = []string{"x-nullable", "x-isnullable"}
+
+// isNullable check if the given extensions mark their schema or parameter
+// as nullable.
+func isNullable(ext spec.Extensions) bool {
+	for key, value := range ext {
+		for _, name := range nullableExtensions {
+			if strings.EqualFold(key, name) && value == true {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// allowNulls make, inside the whole schema, the nullable schemas accept the
+// null value, unknown to the draft 4 validators.
+func allowNulls(s *spec.Schema) {
+	eachSubSchema(s, func(keyword string, sub *spec.Schema) {
+		allowNulls(sub)
+	})
+
+	if !isNullable(s.Extensions) {
+		return
+	}
+
+	if len(s.Type) > 0 && len(s.Enum) == 0 {
+		if !s.Type.Contains("null") {
+			s.Type = append(s.Type, "null")
+		}
+		return
+	}
+
+	// Without type, as for the compositions, or with an enum, which can't
+	// contain null, the null value is allowed as an alternative to the whole
+	// schema.
+	for key := range s.Extensions {
+		for _, name := range nullableExtensions {
+			if strings.EqualFold(key, name) {
+				delete(s.Extensions, key)
+			}
+		}
+	}
+
+	*s = spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			AnyOf: []spec.Schema{
+				{SchemaProps: spec.SchemaProps{Type: spec.StringOrArray{"null"}}},
+				*s,
+			},
+		},
+	}
+}
+
+// dropNulls remove, inside the whole value, the object properties set to
+// null, in order to consider them as absent.
+func dropNulls(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, sub := range value {
+			if sub == nil {
+				delete(value, key)
+				continue
+			}
+
+			value[key] = dropNulls(sub)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = dropNulls(item)
+		}
+	}
+
+	return value
+}
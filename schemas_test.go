@@ -0,0 +1,413 @@
+package oaichecker
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/validate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSchema(t *testing.T, rawSchema string) *spec.Schema {
+	var res spec.Schema
+	err := json.Unmarshal([]byte(rawSchema), &res)
+	require.NoError(t, err)
+
+	return &res
+}
+
+func Test_closeObjects(t *testing.T) {
+	schema := newTestSchema(t, `{
+		"type": "object",
+		"properties": {
+			"open": {"type": "object", "additionalProperties": true},
+			"items": {"type": "array", "items": {"properties": {"id": {"type": "integer"}}}},
+			"composed": {
+				"allOf": [
+					{"type": "object", "properties": {"name": {"type": "string"}}},
+					{"type": "object", "properties": {"tag": {"type": "string"}}}
+				]
+			}
+		}
+	}`)
+
+	closeObjects(schema)
+
+	assert.Equal(t, &spec.SchemaOrBool{Allows: false}, schema.AdditionalProperties)
+	assert.Equal(t, &spec.SchemaOrBool{Allows: true}, schema.Properties["open"].AdditionalProperties)
+	assert.Equal(t, &spec.SchemaOrBool{Allows: false}, schema.Properties["items"].Items.Schema.AdditionalProperties)
+
+	// The composed schema is closed with the properties of its parts.
+	composed := schema.Properties["composed"]
+	assert.Equal(t, &spec.SchemaOrBool{Allows: false}, composed.AdditionalProperties)
+	assert.Contains(t, composed.Properties, "name")
+	assert.Contains(t, composed.Properties, "tag")
+	assert.Nil(t, composed.AllOf[0].AdditionalProperties)
+	assert.Nil(t, composed.AllOf[1].AdditionalProperties)
+}
+
+func Test_Analyzer_bodySchema_is_cached_and_copied(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzerWithOptions(specs, AnalyzerOptions{
+		RejectUnknownResponseProperties: true,
+	})
+
+	r := analyzer.routes.Load().(*routes)
+
+	schema := newTestSchema(t, `{"type": "object"}`)
+
+	res := analyzer.bodySchema(r, schema, PhaseResponse)
+	assert.Equal(t, &spec.SchemaOrBool{Allows: false}, res.AdditionalProperties)
+	assert.Nil(t, schema.AdditionalProperties)
+	assert.True(t, res == analyzer.bodySchema(r, schema, PhaseResponse))
+
+	// No transformation is configured for the requests.
+	assert.True(t, schema == analyzer.bodySchema(r, schema, PhaseRequest))
+
+	// The readOnly properties are dropped from the required ones.
+	readOnly := newTestSchema(t, `{
+		"required": ["id"],
+		"properties": {"id": {"type": "integer", "readOnly": true}}
+	}`)
+
+	res = analyzer.bodySchema(r, readOnly, PhaseRequest)
+	assert.False(t, readOnly == res)
+	assert.Empty(t, res.Required)
+	assert.Equal(t, []string{"id"}, readOnly.Required)
+}
+
+func Test_Analyzer_Analyze_with_unknown_response_properties(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzerWithOptions(specs, AnalyzerOptions{
+		RejectUnknownResponseProperties: true,
+	})
+
+	req, err := http.NewRequest("GET", "/v2/pet/42", nil)
+	require.NoError(t, err)
+	req.Header.Set("userID", "42")
+
+	body := `{
+		"name": "doggie",
+		"photoUrls": [],
+		"age": 3,
+		"tags": [{"id": 1, "name": "good", "color": "brown"}]
+	}`
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+	}
+
+	err = analyzer.Analyze(req, res)
+
+	require.IsType(t, ValidationErrors{}, err)
+
+	var pointers []string
+	for _, validationErr := range err.(ValidationErrors) {
+		assert.Equal(t, "additionalProperties", validationErr.Keyword)
+		pointers = append(pointers, validationErr.Pointer)
+	}
+
+	assert.ElementsMatch(t, []string{"/age", "/tags/0/color"}, pointers)
+}
+
+func Test_dropRequired(t *testing.T) {
+	schema := newTestSchema(t, `{
+		"required": ["id", "password", "name"],
+		"properties": {
+			"id": {"type": "integer", "readOnly": true},
+			"password": {"type": "string", "writeOnly": true},
+			"name": {"type": "string"}
+		}
+	}`)
+
+	request, err := copySchema(schema)
+	require.NoError(t, err)
+	dropRequired(request, PhaseRequest)
+
+	response, err := copySchema(schema)
+	require.NoError(t, err)
+	dropRequired(response, PhaseResponse)
+
+	assert.Equal(t, []string{"password", "name"}, request.Required)
+	assert.Equal(t, []string{"id", "name"}, response.Required)
+}
+
+var readWriteSpecs = []byte(`{
+	"openapi": "3.0.0",
+	"info": {"title": "users", "version": "1.0.0"},
+	"paths": {
+		"/users": {
+			"post": {
+				"requestBody": {
+					"required": true,
+					"content": {
+						"application/json": {
+							"schema": {"$ref": "#/components/schemas/User"}
+						}
+					}
+				},
+				"responses": {
+					"201": {
+						"description": "created",
+						"content": {
+							"application/json": {
+								"schema": {"$ref": "#/components/schemas/User"}
+							}
+						}
+					}
+				}
+			}
+		}
+	},
+	"components": {
+		"schemas": {
+			"User": {
+				"type": "object",
+				"required": ["id", "name", "password"],
+				"properties": {
+					"id": {"type": "integer", "readOnly": true},
+					"name": {"type": "string"},
+					"password": {"type": "string", "writeOnly": true}
+				}
+			}
+		}
+	}
+}`)
+
+func Test_Analyzer_Analyze_with_read_only_and_write_only_properties(t *testing.T) {
+	specs, err := NewSpecsFromRaw(readWriteSpecs)
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("POST", "/users", bytes.NewBufferString(`{
+		"id": 42,
+		"name": "foo",
+		"password": "secret"
+	}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	body := `{"id": 42, "name": "foo", "password": "secret"}`
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusCreated),
+		StatusCode:    http.StatusCreated,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+	}
+
+	err = analyzer.Analyze(req, res)
+
+	require.IsType(t, ValidationErrors{}, err)
+	require.Len(t, err, 2)
+
+	assert.Equal(t, PhaseRequest, err.(ValidationErrors)[0].Phase)
+	assert.Equal(t, "readOnly", err.(ValidationErrors)[0].Keyword)
+	assert.Equal(t, "/id", err.(ValidationErrors)[0].Pointer)
+
+	assert.Equal(t, PhaseResponse, err.(ValidationErrors)[1].Phase)
+	assert.Equal(t, "writeOnly", err.(ValidationErrors)[1].Keyword)
+	assert.Equal(t, "/password", err.(ValidationErrors)[1].Pointer)
+
+	assert.EqualError(t, err, "validation failure list:\n"+
+		".id in body is read only\n"+
+		".password in body is write only")
+}
+
+func Test_Analyzer_Analyze_without_read_only_and_write_only_properties(t *testing.T) {
+	specs, err := NewSpecsFromRaw(readWriteSpecs)
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("POST", "/users", bytes.NewBufferString(`{
+		"name": "foo",
+		"password": "secret"
+	}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	body := `{"id": 42, "name": "foo"}`
+
+	res := &http.Response{
+		Status:        http.StatusText(http.StatusCreated),
+		StatusCode:    http.StatusCreated,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+	}
+
+	err = analyzer.Analyze(req, res)
+
+	assert.NoError(t, err)
+}
+
+func Test_allowNulls(t *testing.T) {
+	schema := newTestSchema(t, `{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "x-nullable": true},
+			"status": {"type": "string", "enum": ["a", "b"], "x-isnullable": true},
+			"owner": {"allOf": [{"type": "object"}], "x-nullable": true},
+			"tags": {"type": "array"}
+		}
+	}`)
+
+	allowNulls(schema)
+
+	assert.Equal(t, spec.StringOrArray{"string", "null"}, schema.Properties["name"].Type)
+	assert.Len(t, schema.Properties["status"].AnyOf, 2)
+	assert.Len(t, schema.Properties["owner"].AnyOf, 2)
+	assert.Equal(t, spec.StringOrArray{"array"}, schema.Properties["tags"].Type)
+
+	assert.NoError(t, validate.AgainstSchema(schema, map[string]interface{}{
+		"name":   nil,
+		"status": nil,
+		"owner":  nil,
+	}, strfmt.Default))
+	assert.Error(t, validate.AgainstSchema(schema, map[string]interface{}{
+		"tags": nil,
+	}, strfmt.Default))
+}
+
+func Test_dropNulls(t *testing.T) {
+	value := map[string]interface{}{
+		"name": nil,
+		"owner": map[string]interface{}{
+			"id":   float64(1),
+			"name": nil,
+		},
+		"tags": []interface{}{nil, map[string]interface{}{"name": nil}},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"owner": map[string]interface{}{
+			"id": float64(1),
+		},
+		"tags": []interface{}{nil, map[string]interface{}{}},
+	}, dropNulls(value))
+}
+
+var nullableSpecs = []byte(`{
+	"openapi": "3.0.0",
+	"info": {"title": "pets", "version": "1.0.0"},
+	"paths": {
+		"/pets": {
+			"get": {
+				"parameters": [
+					{"name": "limit", "in": "query", "schema": {"type": "integer", "nullable": true}}
+				],
+				"responses": {
+					"200": {
+						"description": "pet",
+						"content": {
+							"application/json": {
+								"schema": {
+									"type": "object",
+									"required": ["name"],
+									"properties": {
+										"name": {"type": "string"},
+										"owner": {"type": "object", "nullable": true},
+										"tag": {"type": "string"}
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}`)
+
+func Test_Analyzer_Analyze_with_nullable_values(t *testing.T) {
+	specs, err := NewSpecsFromRaw(nullableSpecs)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		options AnalyzerOptions
+		body    string
+		err     string
+	}{
+		{
+			name: "nullable property",
+			body: `{"name": "foo", "owner": null}`,
+		},
+		{
+			name: "not nullable property",
+			body: `{"name": "foo", "tag": null}`,
+			err:  ".tag in body must be of type string",
+		},
+		{
+			name:    "null as absent",
+			options: AnalyzerOptions{NullAsAbsent: true},
+			body:    `{"name": "foo", "tag": null}`,
+		},
+		{
+			name:    "required null as absent",
+			options: AnalyzerOptions{NullAsAbsent: true},
+			body:    `{"name": null}`,
+			err:     ".name in body is required",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			analyzer := NewAnalyzerWithOptions(specs, test.options)
+
+			req, err := http.NewRequest("GET", "/pets?limit=", nil)
+			require.NoError(t, err)
+
+			res := &http.Response{
+				Status:        http.StatusText(http.StatusOK),
+				StatusCode:    http.StatusOK,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Body:          ioutil.NopCloser(bytes.NewBufferString(test.body)),
+				ContentLength: int64(len(test.body)),
+				Request:       req,
+				Header:        http.Header{"Content-Type": []string{"application/json"}},
+			}
+
+			err = analyzer.Analyze(req, res)
+
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				require.IsType(t, ValidationErrors{}, err)
+				require.Len(t, err, 1)
+				assert.Contains(t, err.Error(), test.err)
+			}
+		})
+	}
+}
@@ -2,10 +2,14 @@ package oaichecker
 
 import (
 	"encoding/json"
+	"net/http"
+	"path/filepath"
 
 	"github.com/go-openapi/analysis"
 	"github.com/go-openapi/loads"
+	"github.com/go-openapi/spec"
 	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
 	"github.com/go-openapi/validate"
 )
 
@@ -14,62 +18,370 @@ import (
 // It can be used inside an Analyzer or a Transport.
 type Specs struct {
 	document *loads.Document
+
+	// validationDocument is the document checked by Report, whose references
+	// are kept in order to check the definitions usage.
+	validationDocument *loads.Document
+
+	version   string
+	basePaths []string
+	hosts     []string
+	schemes   []string
 }
 
 // NewSpecsFromFile load a new OpenAPI specifications file from a filepath.
 //
-// This specs can be either in JSON or YAML format. Any relatives references
-// ("$ref": "./pet_definition.json" for example) will be resolved based on the
-// given path.
+// This specs can be either in JSON or YAML format and follow either the
+// Swagger 2.0, the OpenAPI 3.0 or the OpenAPI 3.1 specifications. Any
+// relatives references ("$ref": "./pet_definition.json" for example) will be
+// resolved based on the given path.
 func NewSpecsFromFile(path string) (*Specs, error) {
-	doc, err := loads.Spec(path)
+	location := filepath.ToSlash(path)
+
+	rawSpec, err := fileLoader(location)
 	if err != nil {
 		return nil, err
 	}
 
-	err = analysis.Flatten(analysis.FlattenOpts{
-		Spec:     doc.Analyzer,
-		BasePath: path,
-		Expand:   true,
-	})
-	if err != nil {
-		return nil, err
+	return newSpecs(rawSpec, location, fileLoader)
+}
+
+// NewSpecsFromRaw load a new raw OpenAPI specifications.
+//
+// This specs can be either in JSON or YAML format and follow either the
+// Swagger 2.0, the OpenAPI 3.0 or the OpenAPI 3.1 specifications. Any
+// relatives references ("$ref": "./pet_definition.json" for example) will be
+// resolved based on the result of os.Getwd().
+func NewSpecsFromRaw(rawSpec []byte) (*Specs, error) {
+	return newSpecs(rawSpec, "", fileLoader)
+}
+
+// RawOptions configure the resolution of the relative references for
+// NewSpecsFromRawWithOptions.
+type RawOptions struct {
+	// BasePath is the file path the raw specs come from. The references are
+	// resolved relatively to it, as for NewSpecsFromFile.
+	BasePath string
+
+	// BaseURL is the URL the raw specs come from. The references are fetched
+	// relatively to it, as for NewSpecsFromURL.
+	BaseURL string
+
+	// Resolver, if set, loads the referenced documents. It receives their
+	// location resolved against BaseURL, or BasePath if BaseURL is empty.
+	Resolver func(location string) ([]byte, error)
+}
+
+// NewSpecsFromRawWithOptions load a new raw OpenAPI specifications, resolving
+// its relative references according to the given options.
+//
+// With the zero RawOptions, it behaves like NewSpecsFromRaw.
+func NewSpecsFromRawWithOptions(rawSpec []byte, opts RawOptions) (*Specs, error) {
+	switch {
+	case opts.Resolver != nil && opts.BaseURL != "":
+		return newSpecs(rawSpec, opts.BaseURL, opts.Resolver)
+	case opts.Resolver != nil:
+		return newSpecs(rawSpec, filepath.ToSlash(opts.BasePath), opts.Resolver)
+	case opts.BaseURL != "":
+		return newSpecs(rawSpec, opts.BaseURL, httpLoader(http.DefaultClient, ""))
+	default:
+		return newSpecs(rawSpec, filepath.ToSlash(opts.BasePath), fileLoader)
 	}
+}
 
-	spec := Specs{
-		document: doc,
+// NewSpecsFromURL load a new OpenAPI specifications from an URL.
+//
+// This specs can be either in JSON or YAML format. Any relatives references
+// ("$ref": "./pet_definition.json" for example) will be fetched based on the
+// given URL.
+//
+// If cacheDir is not empty, the specs and all the referenced documents are
+// saved inside it after their first download and then always read from
+// there, allowing to work offline. Empty the directory to refresh them.
+func NewSpecsFromURL(specURL string, cacheDir string) (*Specs, error) {
+	load := httpLoader(http.DefaultClient, cacheDir)
+
+	rawSpec, err := load(specURL)
+	if err != nil {
+		return nil, err
 	}
 
-	return &spec, nil
+	return newSpecs(rawSpec, specURL, load)
 }
 
-// NewSpecsFromRaw load a new raw OpenAPI specifications.
+// newSpecs load the given raw specs found at location.
 //
-// This specs can be either in JSON or YAML format. Any relatives references
-// ("$ref": "./pet_definition.json" for example) will be resolved based on the
-// result of os.Getwd().
-func NewSpecsFromRaw(rawSpec []byte) (*Specs, error) {
+// The external references are resolved with load first, relatively to
+// location, in order to convert the referenced OpenAPI 3 objects as well.
+func newSpecs(rawSpec []byte, location string, load documentLoader) (*Specs, error) {
+	var basePaths, hosts, schemes []string
+	version := swaggerVersion
+
+	// The unreadable documents are given as is to go-openapi in order to
+	// keep its error messages.
+	doc, err := readDocument(rawSpec)
+	if err == nil {
+		rawSpec, err = inlineExternalRefs(rawSpec, location, load)
+		if err != nil {
+			return nil, err
+		}
+
+		doc, err = readDocument(rawSpec)
+	}
+	if err == nil && isOpenAPI3(doc) {
+		version, _ = doc["openapi"].(string)
+
+		for _, u := range serverURLs(doc) {
+			basePaths = append(basePaths, u.Path)
+
+			if u.Host != "" {
+				hosts = append(hosts, u.Host)
+			}
+
+			if u.Scheme != "" {
+				schemes = append(schemes, u.Scheme)
+			}
+		}
+
+		doc = convertOpenAPI3(doc)
+	}
+
+	if err == nil {
+		completeDiscriminatorMappings(doc)
+
+		rawSpec, err = json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	document, err := loads.Analyzed(json.RawMessage(rawSpec), "")
 	if err != nil {
 		return nil, err
 	}
 
+	// The references are kept as is in order to check the definitions
+	// usage.
+	validationDocument, err := loads.Analyzed(json.RawMessage(rawSpec), "")
+	if err != nil {
+		return nil, err
+	}
+
 	err = analysis.Flatten(analysis.FlattenOpts{
-		Spec:     document.Analyzer,
-		BasePath: "",
-		Expand:   true,
+		Spec:    validationDocument.Analyzer,
+		Minimal: true,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	err = analysis.Flatten(analysis.FlattenOpts{
+		Spec:   document.Analyzer,
+		Expand: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = expandContents(document)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == swaggerVersion {
+		swagger := document.Spec()
+
+		if swagger.BasePath != "" {
+			basePaths = []string{swagger.BasePath}
+		}
+
+		if swagger.Host != "" {
+			hosts = []string{swagger.Host}
+		}
+
+		schemes = swagger.Schemes
+	}
+
 	spec := Specs{
-		document: document,
+		document:           document,
+		validationDocument: validationDocument,
+		version:            version,
+		basePaths:          basePaths,
+		hosts:              hosts,
+		schemes:            schemes,
 	}
 
 	return &spec, nil
 }
 
+// expandContents replace the content maps converted from OpenAPI 3 by their
+// expanded schemas, indexed by media type.
+func expandContents(document *loads.Document) error {
+	root := document.Spec()
+	if root.Paths == nil {
+		return nil
+	}
+
+	for _, item := range root.Paths.Paths {
+		for _, param := range item.Parameters {
+			err := expandContent(param.Extensions, root)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, operation := range pathItemOperations(item) {
+			for _, param := range operation.Parameters {
+				err := expandContent(param.Extensions, root)
+				if err != nil {
+					return err
+				}
+			}
+
+			if operation.Responses == nil {
+				continue
+			}
+
+			if operation.Responses.Default != nil {
+				err := expandContent(operation.Responses.Default.Extensions, root)
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, response := range operation.Responses.StatusCodeResponses {
+				err := expandContent(response.Extensions, root)
+				if err != nil {
+					return err
+				}
+			}
+
+			err := expandRanges(operation.Responses.Extensions, root)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func expandContent(ext spec.Extensions, root *spec.Swagger) error {
+	rawContent, ok := ext[contentExtension]
+	if !ok {
+		return nil
+	}
+
+	rawJSON, err := json.Marshal(rawContent)
+	if err != nil {
+		return err
+	}
+
+	var content map[string]*spec.Schema
+	err = json.Unmarshal(rawJSON, &content)
+	if err != nil {
+		return err
+	}
+
+	for _, schema := range content {
+		err = spec.ExpandSchema(schema, root, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	ext[contentExtension] = content
+
+	return nil
+}
+
+// expandRanges decode and expand the OpenAPI 3 responses found inside the
+// ranges vendor extension, they are skipped by the go-openapi expansion.
+func expandRanges(ext spec.Extensions, root *spec.Swagger) error {
+	rawRanges, ok := ext[rangesExtension]
+	if !ok {
+		return nil
+	}
+
+	rawJSON, err := json.Marshal(rawRanges)
+	if err != nil {
+		return err
+	}
+
+	var ranges map[string]*spec.Response
+	err = json.Unmarshal(rawJSON, &ranges)
+	if err != nil {
+		return err
+	}
+
+	for _, response := range ranges {
+		if response.Schema != nil {
+			err = spec.ExpandSchema(response.Schema, root, nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = expandContent(response.Extensions, root)
+		if err != nil {
+			return err
+		}
+	}
+
+	ext[rangesExtension] = ranges
+
+	return nil
+}
+
+// pathItemOperations list the operations defined inside a path item, indexed
+// by method.
+func pathItemOperations(item spec.PathItem) map[string]*spec.Operation {
+	res := map[string]*spec.Operation{}
+
+	for method, operation := range map[string]*spec.Operation{
+		http.MethodGet:     item.Get,
+		http.MethodPut:     item.Put,
+		http.MethodPost:    item.Post,
+		http.MethodDelete:  item.Delete,
+		http.MethodOptions: item.Options,
+		http.MethodHead:    item.Head,
+		http.MethodPatch:   item.Patch,
+	} {
+		if operation != nil {
+			res[method] = operation
+		}
+	}
+
+	return res
+}
+
+// readDocument decode a JSON or YAML document into a generic map.
+func readDocument(rawSpec []byte) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+
+	err := json.Unmarshal(rawSpec, &doc)
+	if err == nil {
+		return doc, nil
+	}
+
+	yamlDoc, err := swag.BytesToYAMLDoc(rawSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	rawJSON, err := swag.YAMLToJSON(yamlDoc)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(rawJSON, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
 // Validate the specs correctness.
 //
 // It checks that:
@@ -91,13 +403,50 @@ func NewSpecsFromRaw(rawSpec []byte) (*Specs, error) {
 // - Headers must not contain $ref
 // - Schema and property examples provided must validate against their respective object's schema
 // - Examples provided must validate their schema
+//
+// The OpenAPI 3 specs are checked on their Swagger 2.0 equivalent, built at
+// load time, with the schema keywords unknown to Swagger 2.0 allowed.
+//
+// The warnings are ignored, use Report to retrieve them.
 func (t *Specs) Validate() error {
-	validator := validate.NewSpecValidator(t.document.Schema(), strfmt.Default)
+	return t.Report().AsError()
+}
 
-	errs, _ := validator.Validate(t.document)
-	if len(errs.Errors) > 0 {
-		return errs.AsError()
+// Report validate the specs correctness, as Validate, and return all the
+// errors and warnings found.
+func (t *Specs) Report() *SpecsReport {
+	schema := t.validationDocument.Schema()
+	if t.version != swaggerVersion {
+		schema = relaxSchemaObject(schema)
 	}
 
-	return nil
+	validator := validate.NewSpecValidator(schema, strfmt.Default)
+
+	errs, warnings := validator.Validate(t.validationDocument)
+	if t.version != swaggerVersion && warnings != nil {
+		warnings.Errors = openAPI3Warnings(warnings.Errors, t.validationDocument.Spec())
+	}
+
+	return newSpecsReport(errs, warnings)
+}
+
+// relaxSchemaObject return a copy of the Swagger 2.0 meta schema accepting
+// any keyword inside the schema objects.
+func relaxSchemaObject(metaSchema *spec.Schema) *spec.Schema {
+	res := *metaSchema
+	res.Definitions = spec.Definitions{}
+
+	// Without id the references are resolved against this copy and not
+	// against the cached original.
+	res.ID = ""
+
+	for name, def := range metaSchema.Definitions {
+		if name == "schema" {
+			def.AdditionalProperties = &spec.SchemaOrBool{Allows: true}
+		}
+
+		res.Definitions[name] = def
+	}
+
+	return &res
 }
@@ -0,0 +1,27 @@
+//go:build go1.16
+// +build go1.16
+
+package oaichecker
+
+import (
+	"io/fs"
+)
+
+// NewSpecsFromFS load a new OpenAPI specifications file from the given
+// filesystem, an embed.FS for example.
+//
+// This specs can be either in JSON or YAML format. Any relatives references
+// ("$ref": "./pet_definition.json" for example) will be resolved inside fsys,
+// based on the given path.
+func NewSpecsFromFS(fsys fs.FS, path string) (*Specs, error) {
+	load := func(location string) ([]byte, error) {
+		return fs.ReadFile(fsys, location)
+	}
+
+	rawSpec, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSpecs(rawSpec, path, load)
+}
@@ -0,0 +1,109 @@
+//go:build go1.16
+// +build go1.16
+
+package oaichecker
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewSpecsFromFS_with_multi_file_spec(t *testing.T) {
+	specs, err := NewSpecsFromFS(os.DirFS("./dataset"), "multi_file_spec/petstore_minimal.json")
+
+	require.NoError(t, err)
+	assert.Contains(t, specs.document.Spec().Definitions["Pet"].Properties, "name")
+}
+
+func Test_NewSpecsFromFS_with_invalid_ref(t *testing.T) {
+	specs, err := NewSpecsFromFS(os.DirFS("./dataset"), "petstore_invalid_ref.json")
+
+	assert.Nil(t, specs)
+	assert.EqualError(t, err, "open invalid-file-ref.json: no such file or directory")
+}
+
+func Test_NewSpecsFromFS_with_unknown_file(t *testing.T) {
+	specs, err := NewSpecsFromFS(fstest.MapFS{}, "petstore.json")
+
+	assert.Nil(t, specs)
+	assert.EqualError(t, err, "open petstore.json: file does not exist")
+}
+
+func Test_NewSpecsFromFS_with_nested_refs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"specs/petstore.yaml": &fstest.MapFile{Data: []byte(`
+swagger: "2.0"
+info:
+  title: Swagger Petstore
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      responses:
+        200:
+          description: A list of pets.
+          schema:
+            $ref: "./models/pets.yaml#/Pets"
+`)},
+		"specs/models/pets.yaml": &fstest.MapFile{Data: []byte(`
+Pets:
+  type: array
+  items:
+    $ref: "#/Pet"
+Pet:
+  $ref: "../../common/named.yaml"
+`)},
+		"common/named.yaml": &fstest.MapFile{Data: []byte(`
+type: object
+properties:
+  name:
+    type: string
+`)},
+	}
+
+	specs, err := NewSpecsFromFS(fsys, "specs/petstore.yaml")
+	require.NoError(t, err)
+
+	operation, ok := specs.document.Analyzer.OperationFor("GET", "/pets")
+	require.True(t, ok)
+
+	schema := operation.Responses.StatusCodeResponses[200].Schema
+	assert.Contains(t, schema.Items.Schema.Properties, "name")
+}
+
+func Test_NewSpecsFromFS_with_recursive_external_schema(t *testing.T) {
+	fsys := fstest.MapFS{
+		"petstore.yaml": &fstest.MapFile{Data: []byte(`
+swagger: "2.0"
+info:
+  title: Swagger Petstore
+  version: 1.0.0
+paths:
+  /nodes:
+    get:
+      responses:
+        200:
+          description: A tree of nodes.
+          schema:
+            $ref: "./node.yaml#/Node"
+`)},
+		"node.yaml": &fstest.MapFile{Data: []byte(`
+Node:
+  type: object
+  properties:
+    children:
+      type: array
+      items:
+        $ref: "#/Node"
+`)},
+	}
+
+	specs, err := NewSpecsFromFS(fsys, "petstore.yaml")
+	require.NoError(t, err)
+
+	assert.Contains(t, specs.document.Spec().Definitions["Node"].Properties, "children")
+}
@@ -1,7 +1,11 @@
 package oaichecker
 
 import (
+	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -77,3 +81,123 @@ func Test_Specs_Validate_with_valid_specs(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func Test_NewSpecsFromFile_with_openapi3_spec(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_oas3.yaml")
+
+	require.NoError(t, err)
+	assert.Equal(t, "3.0.0", specs.version)
+	assert.Equal(t, "/v1", specs.document.Spec().BasePath)
+
+	_, ok := specs.document.Analyzer.OperationFor("POST", "/pets")
+	assert.True(t, ok)
+}
+
+func Test_Specs_Validate_with_openapi3_spec(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_oas3.yaml")
+	require.NoError(t, err)
+
+	err = specs.Validate()
+
+	assert.NoError(t, err)
+}
+
+func Test_NewSpecsFromFile_with_openapi31_spec(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_oas31.yaml")
+
+	require.NoError(t, err)
+	assert.Equal(t, "3.1.0", specs.version)
+	assert.Contains(t, specs.document.Spec().Definitions, "Point")
+}
+
+func newSpecsServer(dir string) *httptest.Server {
+	return httptest.NewServer(http.FileServer(http.Dir(dir)))
+}
+
+func Test_NewSpecsFromURL_with_multi_file_spec(t *testing.T) {
+	ts := newSpecsServer("./dataset")
+	defer ts.Close()
+
+	specs, err := NewSpecsFromURL(ts.URL+"/multi_file_spec/petstore_minimal.json", "")
+
+	require.NoError(t, err)
+	assert.Contains(t, specs.document.Spec().Definitions["Pet"].Properties, "name")
+}
+
+func Test_NewSpecsFromURL_with_unknown_spec(t *testing.T) {
+	ts := newSpecsServer("./dataset")
+	defer ts.Close()
+
+	specs, err := NewSpecsFromURL(ts.URL+"/unknown.json", "")
+
+	assert.Nil(t, specs)
+	assert.EqualError(t, err, fmt.Sprintf("failed to fetch %s/unknown.json: 404 Not Found", ts.URL))
+}
+
+func Test_NewSpecsFromURL_with_cache_dir(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "oaichecker")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	ts := newSpecsServer("./dataset")
+
+	specURL := ts.URL + "/multi_file_spec/petstore_minimal.json"
+
+	_, err = NewSpecsFromURL(specURL, cacheDir)
+	require.NoError(t, err)
+
+	// Every document must now be read from the cache.
+	ts.Close()
+
+	specs, err := NewSpecsFromURL(specURL, cacheDir)
+
+	require.NoError(t, err)
+	assert.Contains(t, specs.document.Spec().Definitions["Pet"].Properties, "name")
+}
+
+func Test_NewSpecsFromRawWithOptions_with_base_path(t *testing.T) {
+	rawSpecs, err := ioutil.ReadFile("./dataset/multi_file_spec/petstore_minimal.json")
+	require.NoError(t, err)
+
+	specs, err := NewSpecsFromRawWithOptions(rawSpecs, RawOptions{
+		BasePath: "./dataset/multi_file_spec/petstore_minimal.json",
+	})
+
+	assert.NotNil(t, specs)
+	assert.NoError(t, err)
+}
+
+func Test_NewSpecsFromRawWithOptions_with_base_url(t *testing.T) {
+	ts := newSpecsServer("./dataset")
+	defer ts.Close()
+
+	rawSpecs, err := ioutil.ReadFile("./dataset/multi_file_spec/petstore_minimal.json")
+	require.NoError(t, err)
+
+	specs, err := NewSpecsFromRawWithOptions(rawSpecs, RawOptions{
+		BaseURL: ts.URL + "/multi_file_spec/",
+	})
+
+	require.NoError(t, err)
+	assert.Contains(t, specs.document.Spec().Definitions["Pet"].Properties, "name")
+}
+
+func Test_NewSpecsFromRawWithOptions_with_resolver(t *testing.T) {
+	rawSpecs, err := ioutil.ReadFile("./dataset/multi_file_spec/petstore_minimal.json")
+	require.NoError(t, err)
+
+	var locations []string
+
+	specs, err := NewSpecsFromRawWithOptions(rawSpecs, RawOptions{
+		BasePath: "specs/petstore.json",
+		Resolver: func(location string) ([]byte, error) {
+			locations = append(locations, location)
+
+			return ioutil.ReadFile("./dataset/multi_file_spec/pet_definition.json")
+		},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"specs/pet_definition.json"}, locations)
+	assert.Contains(t, specs.document.Spec().Definitions["Pet"].Properties, "name")
+}
@@ -0,0 +1,132 @@
+package oaichecker
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+
+	"github.com/go-openapi/spec"
+)
+
+// standardHeaders are the request headers allowed by
+// AnalyzerOptions.RejectUnknownHeaders without being declared.
+var standardHeaders = []string{
+	"Accept", "Accept-Charset", "Accept-Encoding", "Accept-Language",
+	"Authorization", "Cache-Control", "Connection", "Content-Encoding",
+	"Content-Length", "Content-Type", "Cookie", "Date", "Expect", "Forwarded",
+	"Host", "If-Match", "If-Modified-Since", "If-None-Match", "If-Range",
+	"If-Unmodified-Since", "Origin", "Pragma", "Range", "Referer", "Te",
+	"Trailer", "Transfer-Encoding", "Upgrade", "User-Agent", "Via",
+	"X-Forwarded-For", "X-Forwarded-Host", "X-Forwarded-Proto",
+}
+
+// apiKeys list the parameters used by the API key security schemes, indexed
+// by location.
+func apiKeys(schemes map[string]spec.SecurityScheme) map[string][]string {
+	res := map[string][]string{}
+	for _, scheme := range schemes {
+		if scheme.Type == "apiKey" {
+			res[scheme.In] = append(res[scheme.In], scheme.Name)
+		}
+	}
+
+	return res
+}
+
+// declaredParameters list the names of the parameters declared in the given
+// location, API keys included.
+func (t *exchange) declaredParameters(in string) map[string]bool {
+	res := map[string]bool{}
+	for _, param := range t.parameters {
+		if param.In == in {
+			res[param.Name] = true
+		}
+	}
+
+	for _, name := range t.apiKeys[in] {
+		res[name] = true
+	}
+
+	return res
+}
+
+// validateUnknownParameters report the query parameters and the form fields
+// not declared by the operation.
+func (t *Analyzer) validateUnknownParameters(ex *exchange, req *http.Request) {
+	declared := ex.declaredParameters("query")
+	for _, name := range sortedValuesKeys(req.URL.Query()) {
+		if !declared[name] {
+			ex.add(PhaseRequest, "query", name, ex.specPointer("parameters"), unknownParameter(name, "query"))
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if !isFormMediaType(mediaType) {
+		return
+	}
+
+	form, err := ex.formRequest(req)
+	if err != nil {
+		return
+	}
+
+	fields := map[string][]string{}
+	for name, values := range form.PostForm {
+		fields[name] = values
+	}
+
+	if form.MultipartForm != nil {
+		for name := range form.MultipartForm.File {
+			fields[name] = nil
+		}
+	}
+
+	declared = ex.declaredParameters("formData")
+	for _, name := range sortedValuesKeys(fields) {
+		if !declared[name] {
+			ex.add(PhaseRequest, "formData", name, ex.specPointer("parameters"), unknownParameter(name, "formData"))
+		}
+	}
+}
+
+// validateUnknownHeaders report the request headers not declared by the
+// operation, except the standard and the ignored ones.
+func (t *Analyzer) validateUnknownHeaders(ex *exchange, req *http.Request) {
+	allowed := map[string]bool{}
+	for _, names := range [][]string{standardHeaders, t.options.IgnoredHeaders} {
+		for _, name := range names {
+			allowed[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+
+	for name := range ex.declaredParameters("header") {
+		allowed[http.CanonicalHeaderKey(name)] = true
+	}
+
+	for _, name := range sortedValuesKeys(req.Header) {
+		if !allowed[http.CanonicalHeaderKey(name)] {
+			ex.add(PhaseRequest, "header", name, ex.specPointer("parameters"), unknownParameter(name, "header"))
+		}
+	}
+}
+
+func unknownParameter(name string, in string) violation {
+	return violation{
+		keyword: "parameters",
+		message: fmt.Sprintf("%s in %s is not defined inside the specs", name, in),
+	}
+}
+
+// sortedValuesKeys return the keys of url.Values or http.Header, sorted in
+// order to always report the violations in the same order.
+func sortedValuesKeys(values map[string][]string) []string {
+	res := make([]string, 0, len(values))
+	for key := range values {
+		res = append(res, key)
+	}
+
+	sort.Strings(res)
+
+	return res
+}
@@ -0,0 +1,140 @@
+package oaichecker
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Analyzer_AnalyzeRequest_with_unknown_query_parameters(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzerWithOptions(specs, AnalyzerOptions{
+		RejectUnknownParameters: true,
+	})
+
+	req, err := http.NewRequest("GET", "/v2/user/login?username=foo&password=bar&pasword=bar", nil)
+	require.NoError(t, err)
+
+	err = analyzer.AnalyzeRequest(req)
+
+	assert.EqualError(t, err, "validation failure list:\n"+
+		"pasword in query is not defined inside the specs")
+}
+
+func Test_Analyzer_AnalyzeRequest_with_path_level_query_parameter(t *testing.T) {
+	specs, err := NewSpecsFromRaw([]byte(`{
+		"swagger": "2.0",
+		"info": {"title": "Petstore", "version": "1.0.0"},
+		"paths": {
+			"/pets": {
+				"parameters": [{"name": "limit", "in": "query", "type": "integer"}],
+				"get": {"responses": {"200": {"description": "The pets."}}}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzerWithOptions(specs, AnalyzerOptions{
+		RejectUnknownParameters: true,
+	})
+
+	req, err := http.NewRequest("GET", "/pets?limit=3", nil)
+	require.NoError(t, err)
+
+	err = analyzer.AnalyzeRequest(req)
+
+	assert.NoError(t, err)
+}
+
+func Test_Analyzer_AnalyzeRequest_with_unknown_form_fields(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzerWithOptions(specs, AnalyzerOptions{
+		RejectUnknownParameters: true,
+	})
+
+	req, err := http.NewRequest("POST", "/v2/pet/42", strings.NewReader("name=doggie&color=brown"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	err = analyzer.AnalyzeRequest(req)
+
+	assert.EqualError(t, err, "validation failure list:\n"+
+		"color in formData is not defined inside the specs")
+}
+
+func Test_Analyzer_AnalyzeRequest_with_unknown_headers(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzerWithOptions(specs, AnalyzerOptions{
+		RejectUnknownHeaders: true,
+		IgnoredHeaders:       []string{"x-request-id"},
+	})
+
+	req, err := http.NewRequest("GET", "/v2/store/inventory", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Api_key", "some-key")
+	req.Header.Set("X-Request-Id", "some-id")
+	req.Header.Set("X-Debug", "true")
+
+	err = analyzer.AnalyzeRequest(req)
+
+	assert.EqualError(t, err, "validation failure list:\n"+
+		"X-Debug in header is not defined inside the specs")
+}
+
+func Test_Analyzer_AnalyzeRequest_with_openapi3_api_key_header(t *testing.T) {
+	specs, err := NewSpecsFromRaw([]byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "notes", "version": "1.0.0"},
+		"security": [{"key": []}],
+		"paths": {
+			"/notes": {
+				"get": {
+					"responses": {"200": {"description": "notes"}}
+				}
+			}
+		},
+		"components": {
+			"securitySchemes": {
+				"key": {"type": "apiKey", "in": "header", "name": "X-API-Key"}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzerWithOptions(specs, AnalyzerOptions{
+		RejectUnknownHeaders: true,
+	})
+
+	req, err := http.NewRequest("GET", "/notes", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-API-Key", "secret")
+
+	err = analyzer.AnalyzeRequest(req)
+
+	assert.NoError(t, err)
+}
+
+func Test_Analyzer_AnalyzeRequest_without_strict_mode(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore.json")
+	require.NoError(t, err)
+
+	analyzer := NewAnalyzer(specs)
+
+	req, err := http.NewRequest("GET", "/v2/user/login?username=foo&password=bar&pasword=bar", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Debug", "true")
+
+	err = analyzer.AnalyzeRequest(req)
+
+	assert.NoError(t, err)
+}
@@ -5,6 +5,8 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
 )
 
 // Transport is a http.RoundTripper implementation destined to be injected
@@ -13,19 +15,53 @@ import (
 // It allows to make HTTP calls as usual but it will intercept the
 // http.Request and http.Response and will validate them against the OpenAPI
 // specs given during instantiation.
+//
+// The requests aimed at a host or a scheme outside of Hosts and Schemes are
+// sent as is, without any validation. An empty list accepts everything.
 type Transport struct {
 	Transport http.RoundTripper
+	Hosts     []string
+	Schemes   []string
 	analyzer  *Analyzer
 }
 
 // NewTransport instantiate a new Transport with the given Specs.
+//
+// All the requests are validated, whatever their host and scheme.
 func NewTransport(specs *Specs) *Transport {
+	return NewTransportWithAnalyzer(NewAnalyzer(specs))
+}
+
+// NewTransportWithAnalyzer instantiate a new Transport validating the
+// requests with the given Analyzer, configured with its own options and
+// codecs, or kept up to date by a SpecsWatcher.
+//
+// All the requests are validated, whatever their host and scheme.
+//
+// If the analyzer is nil, the function panics.
+func NewTransportWithAnalyzer(analyzer *Analyzer) *Transport {
+	if analyzer == nil {
+		panic("analyzer is nil")
+	}
+
 	return &Transport{
 		Transport: http.DefaultTransport,
-		analyzer:  NewAnalyzer(specs),
+		analyzer:  analyzer,
 	}
 }
 
+// NewScopedTransport instantiate a new Transport with the given Specs.
+//
+// Only the requests matching the specs host and schemes (or the OpenAPI 3
+// servers) are validated, the others are sent as is.
+func NewScopedTransport(specs *Specs) *Transport {
+	transport := NewTransport(specs)
+	transport.Hosts = specs.hosts
+	transport.Schemes = specs.schemes
+
+	return transport
+}
+
 // RoundTrip implement http.RoundTripper.
 //
 // If a validation error occures an error will returned with a new Response.
@@ -35,6 +71,10 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 		body []byte
 	)
 
+	if !t.inScope(req.URL) {
+		return t.Transport.RoundTrip(req)
+	}
+
 	// GetBody is an optional func to return a new copy of Body
 	switch req.Body.(type) {
 	case nil:
@@ -69,3 +109,33 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 
 	return res, err
 }
+
+// inScope check if the given URL matches the Hosts and Schemes.
+func (t *Transport) inScope(u *url.URL) bool {
+	if len(t.Schemes) > 0 && !containsFold(t.Schemes, u.Scheme) {
+		return false
+	}
+
+	if len(t.Hosts) == 0 {
+		return true
+	}
+
+	for _, host := range t.Hosts {
+		// A host without port matches all the ports.
+		if strings.EqualFold(host, u.Host) || strings.EqualFold(host, u.Hostname()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -45,6 +46,7 @@ func Test_Transport_implements_RoundTripper(t *testing.T) {
 
 func Test_Transport_with_a_valid_request(t *testing.T) {
 	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
 		_, err := w.Write([]byte("[]"))
 		require.NoError(t, err)
 	})
@@ -57,12 +59,35 @@ func Test_Transport_with_a_valid_request(t *testing.T) {
 		Transport: NewTransport(specs),
 	}
 
-	res, err := client.Get(ts.URL + "/pets")
+	res, err := client.Get(ts.URL + "/api/pets")
 
 	assert.NoError(t, err)
 	assert.JSONEq(t, `[]`, resBody(t, res))
 }
 
+func Test_TransportWithAnalyzer_uses_the_analyzer_options(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte("[]"))
+		require.NoError(t, err)
+	})
+	defer ts.Close()
+
+	specs, err := NewSpecsFromFile("./dataset/petstore_minimal.json")
+	require.NoError(t, err)
+
+	client := http.Client{
+		Transport: NewTransportWithAnalyzer(NewAnalyzerWithOptions(specs, AnalyzerOptions{
+			RejectUnknownParameters: true,
+		})),
+	}
+
+	res, err := client.Get(ts.URL + "/api/pets?limit=10")
+
+	assert.Nil(t, res)
+	assert.Contains(t, fmt.Sprint(err), "limit in query is not defined inside the specs")
+}
+
 func Test_Transport_with_a_transport_error(t *testing.T) {
 	specs, err := NewSpecsFromFile("./dataset/petstore_minimal.json")
 	require.NoError(t, err)
@@ -77,10 +102,10 @@ func Test_Transport_with_a_transport_error(t *testing.T) {
 		Transport: checkerTransport,
 	}
 
-	res, err := client.Get("http://foobar/pets")
+	res, err := client.Get("http://foobar/api/pets")
 
 	assert.Nil(t, res)
-	assert.EqualError(t, err, "Get http://foobar/pets: some-error")
+	assert.EqualError(t, err, "Get http://foobar/api/pets: some-error")
 
 	mockInnerTransport.AssertExpectations(t)
 }
@@ -99,10 +124,10 @@ func Test_Transport_with_an_analyzer_error(t *testing.T) {
 		Transport: NewTransport(specs),
 	}
 
-	res, err := client.Get(ts.URL + "/invalid-path")
+	res, err := client.Get(ts.URL + "/api/invalid-path")
 
 	assert.Nil(t, res)
-	assert.EqualError(t, err, fmt.Sprintf("Get %s/invalid-path: operation not defined inside the specs", ts.URL))
+	assert.EqualError(t, err, fmt.Sprintf("Get %s/api/invalid-path: operation not defined inside the specs", ts.URL))
 }
 
 func Test_Transport_with_a_body(t *testing.T) {
@@ -120,7 +145,7 @@ func Test_Transport_with_a_body(t *testing.T) {
 		Transport: NewTransport(specs),
 	}
 
-	res, err := client.Post(ts.URL+"/pet", "application/json", strings.NewReader(`{
+	res, err := client.Post(ts.URL+"/v2/pet", "application/json", strings.NewReader(`{
 		"name": "foobar",
 		"photoUrls": ["some-url"]
 	}`))
@@ -129,3 +154,85 @@ func Test_Transport_with_a_body(t *testing.T) {
 	assert.Equal(t, http.StatusCreated, res.StatusCode)
 	assert.Equal(t, "", resBody(t, res))
 }
+
+func Test_ScopedTransport_with_a_request_outside_of_the_specs_host(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_minimal.json")
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader("some-response")),
+		Header:     make(http.Header),
+	}
+
+	mockInnerTransport := new(mockTransport)
+	mockInnerTransport.On("RoundTrip", mock.Anything).Return(res, nil).Once()
+
+	checkerTransport := NewScopedTransport(specs)
+	checkerTransport.Transport = mockInnerTransport
+
+	client := http.Client{
+		Transport: checkerTransport,
+	}
+
+	res, err = client.Get("http://some-third-party-service/invalid-path")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-response", resBody(t, res))
+
+	mockInnerTransport.AssertExpectations(t)
+}
+
+func Test_ScopedTransport_with_a_request_to_the_specs_host(t *testing.T) {
+	specs, err := NewSpecsFromFile("./dataset/petstore_minimal.json")
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader("some-response")),
+		Header:     make(http.Header),
+	}
+
+	mockInnerTransport := new(mockTransport)
+	mockInnerTransport.On("RoundTrip", mock.Anything).Return(res, nil).Once()
+
+	checkerTransport := NewScopedTransport(specs)
+	checkerTransport.Transport = mockInnerTransport
+
+	client := http.Client{
+		Transport: checkerTransport,
+	}
+
+	res, err = client.Get("http://petstore.swagger.io/api/invalid-path")
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "Get http://petstore.swagger.io/api/invalid-path: operation not defined inside the specs")
+
+	mockInnerTransport.AssertExpectations(t)
+}
+
+func Test_Transport_inScope(t *testing.T) {
+	transport := Transport{
+		Hosts:   []string{"petstore.swagger.io", "localhost:8080"},
+		Schemes: []string{"https"},
+	}
+
+	tests := []struct {
+		rawURL   string
+		expected bool
+	}{
+		{"https://petstore.swagger.io/v2/pet", true},
+		{"https://PETSTORE.swagger.io:443/v2/pet", true},
+		{"http://petstore.swagger.io/v2/pet", false},
+		{"https://localhost:8080/v2/pet", true},
+		{"https://localhost:9090/v2/pet", false},
+		{"https://example.com/v2/pet", false},
+	}
+
+	for _, test := range tests {
+		u, err := url.Parse(test.rawURL)
+		require.NoError(t, err)
+
+		assert.Equal(t, test.expected, transport.inScope(u), test.rawURL)
+	}
+}
@@ -0,0 +1,185 @@
+package oaichecker
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SpecsWatcher keep an Analyzer up to date with a specs file.
+//
+// Each time the specs file, or one of the files it references, is modified,
+// the specs are loaded and validated again. If they are correct, they are
+// used by the Analyzer for the following calls, otherwise the previous ones
+// are kept.
+type SpecsWatcher struct {
+	path     string
+	analyzer *Analyzer
+	onError  func(error)
+
+	lock  sync.Mutex
+	files map[string]time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// WatchSpecsFile load and validate the specs file found at path, then check
+// for modifications every interval.
+//
+// Only the local files are watched. The errors occurring during the reloads
+// are given to onError, if not nil.
+func WatchSpecsFile(path string, interval time.Duration, onError func(error)) (*SpecsWatcher, error) {
+	return WatchSpecsFileWithOptions(path, interval, AnalyzerOptions{}, onError)
+}
+
+// WatchSpecsFileWithOptions behave like WatchSpecsFile, the Analyzer being
+// configured with the optional checks enabled by opts.
+func WatchSpecsFileWithOptions(path string, interval time.Duration, opts AnalyzerOptions, onError func(error)) (*SpecsWatcher, error) {
+	specs, files, err := loadWatchedSpecs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := newRoutes(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher := SpecsWatcher{
+		path:     path,
+		analyzer: new(Analyzer),
+		onError:  onError,
+		files:    files,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	watcher.analyzer.options = opts
+	watcher.analyzer.routes.Store(r)
+
+	go watcher.run(interval)
+
+	return &watcher, nil
+}
+
+// Analyzer return the Analyzer using the latest valid specs.
+func (t *SpecsWatcher) Analyzer() *Analyzer {
+	return t.analyzer
+}
+
+// Reload load and validate the specs file immediately, whether it has been
+// modified or not.
+func (t *SpecsWatcher) Reload() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	specs, files, err := loadWatchedSpecs(t.path)
+
+	// Even in case of error, the files are updated in order to wait for
+	// their next modification before retrying.
+	t.files = files
+
+	if err != nil {
+		return err
+	}
+
+	r, err := newRoutes(specs)
+	if err != nil {
+		return err
+	}
+
+	t.analyzer.routes.Store(r)
+
+	return nil
+}
+
+// Close stop watching the files.
+func (t *SpecsWatcher) Close() {
+	close(t.stop)
+	<-t.done
+}
+
+func (t *SpecsWatcher) run(interval time.Duration) {
+	defer close(t.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			if !t.modified() {
+				continue
+			}
+
+			err := t.Reload()
+			if err != nil && t.onError != nil {
+				t.onError(err)
+			}
+		}
+	}
+}
+
+// modified check if one of the watched files has been modified, created or
+// removed since the last load.
+func (t *SpecsWatcher) modified() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for location, modTime := range t.files {
+		if !fileModTime(location).Equal(modTime) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadWatchedSpecs load and validate the specs file at path, returning the
+// modification time of all the files read, even in case of error.
+func loadWatchedSpecs(path string) (*Specs, map[string]time.Time, error) {
+	var locations []string
+
+	load := func(location string) ([]byte, error) {
+		locations = append(locations, location)
+
+		return fileLoader(location)
+	}
+
+	var specs *Specs
+
+	rawSpec, err := load(filepath.ToSlash(path))
+	if err == nil {
+		specs, err = newSpecs(rawSpec, filepath.ToSlash(path), load)
+	}
+
+	if err == nil {
+		err = specs.Validate()
+	}
+
+	files := make(map[string]time.Time, len(locations))
+	for _, location := range locations {
+		files[location] = fileModTime(location)
+	}
+
+	if err != nil {
+		return nil, files, err
+	}
+
+	return specs, files, nil
+}
+
+// fileModTime return the modification time of a file, or the zero time if
+// it doesn't exist.
+func fileModTime(location string) time.Time {
+	info, err := os.Stat(filepath.FromSlash(location))
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
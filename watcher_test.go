@@ -0,0 +1,189 @@
+package oaichecker
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func copySpecsFile(t *testing.T, src string, dst string) {
+	content, err := ioutil.ReadFile(src)
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(dst, content, 0644)
+	require.NoError(t, err)
+
+	// Make sure the modification time changes, whatever the filesystem
+	// precision.
+	modTime := time.Now().Add(time.Duration(len(content)) * time.Second)
+	err = os.Chtimes(dst, modTime, modTime)
+	require.NoError(t, err)
+}
+
+func isOperationDefined(analyzer *Analyzer, method string, path string) bool {
+	r := analyzer.routes.Load().(*routes)
+
+	path, err := r.trimBasePath(path)
+	if err != nil {
+		return false
+	}
+
+	pathName, _, ok := r.router.Lookup(path)
+	if !ok {
+		return false
+	}
+
+	_, ok = r.analyzer.OperationFor(method, pathName.(string))
+
+	return ok
+}
+
+func Test_WatchSpecsFile_with_unknown_file(t *testing.T) {
+	watcher, err := WatchSpecsFile("some-unknown-path", time.Hour, nil)
+
+	assert.Nil(t, watcher)
+	assert.EqualError(t, err, "open some-unknown-path: no such file or directory")
+}
+
+func Test_SpecsWatcher_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oaichecker")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "specs.json")
+	copySpecsFile(t, "./dataset/petstore_minimal.json", path)
+
+	watcher, err := WatchSpecsFile(path, time.Hour, nil)
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	analyzer := watcher.Analyzer()
+
+	assert.True(t, isOperationDefined(analyzer, "GET", "/api/pets"))
+	assert.False(t, isOperationDefined(analyzer, "GET", "/v2/pet/42"))
+
+	copySpecsFile(t, "./dataset/petstore.json", path)
+
+	err = watcher.Reload()
+	require.NoError(t, err)
+
+	assert.False(t, isOperationDefined(analyzer, "GET", "/api/pets"))
+	assert.True(t, isOperationDefined(analyzer, "GET", "/v2/pet/42"))
+}
+
+func Test_WatchSpecsFileWithOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oaichecker")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "specs.json")
+	copySpecsFile(t, "./dataset/petstore_minimal.json", path)
+
+	watcher, err := WatchSpecsFileWithOptions(path, time.Hour, AnalyzerOptions{
+		RejectUnknownParameters: true,
+	}, nil)
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	req, err := http.NewRequest("GET", "/api/pets?limit=10", nil)
+	require.NoError(t, err)
+
+	err = watcher.Analyzer().AnalyzeRequest(req)
+
+	assert.EqualError(t, err, "validation failure list:\n"+
+		"limit in query is not defined inside the specs")
+}
+
+func Test_SpecsWatcher_Reload_drops_the_schemas_cache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oaichecker")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "specs.json")
+	copySpecsFile(t, "./dataset/petstore.json", path)
+
+	watcher, err := WatchSpecsFileWithOptions(path, time.Hour, AnalyzerOptions{
+		RejectUnknownRequestProperties: true,
+	}, nil)
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	cached := func() int {
+		count := 0
+		watcher.Analyzer().routes.Load().(*routes).schemas.Range(func(key, value interface{}) bool {
+			count++
+			return true
+		})
+
+		return count
+	}
+
+	req, err := http.NewRequest("POST", "/v2/pet", strings.NewReader(`{"name": "foo", "photoUrls": []}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	err = watcher.Analyzer().AnalyzeRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cached())
+
+	err = watcher.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, 0, cached())
+}
+
+func Test_SpecsWatcher_Reload_with_invalid_specs_keeps_the_previous_ones(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oaichecker")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "specs.json")
+	copySpecsFile(t, "./dataset/petstore_minimal.json", path)
+
+	watcher, err := WatchSpecsFile(path, time.Hour, nil)
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	copySpecsFile(t, "./dataset/petstore_invalid.json", path)
+
+	err = watcher.Reload()
+
+	assert.Error(t, err)
+	assert.True(t, isOperationDefined(watcher.Analyzer(), "GET", "/api/pets"))
+}
+
+func Test_SpecsWatcher_with_modified_referenced_file(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oaichecker")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "petstore_minimal.json")
+	copySpecsFile(t, "./dataset/multi_file_spec/petstore_minimal.json", path)
+	copySpecsFile(t, "./dataset/multi_file_spec/pet_definition.json", filepath.Join(dir, "pet_definition.json"))
+
+	errs := make(chan error, 10)
+
+	watcher, err := WatchSpecsFile(path, 10*time.Millisecond, func(err error) {
+		errs <- err
+	})
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	err = ioutil.WriteFile(filepath.Join(dir, "pet_definition.json"), []byte("not a json"), 0644)
+	require.NoError(t, err)
+
+	select {
+	case err = <-errs:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the modification of the referenced file has not been detected")
+	}
+
+	assert.True(t, isOperationDefined(watcher.Analyzer(), "GET", "/api/pets"))
+}